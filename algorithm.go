@@ -0,0 +1,219 @@
+package cluster
+
+import (
+	"math"
+
+	"github.com/electrious-go/kdbush"
+)
+
+// Algorithm merges points of the zoom level into clusters, see WithAlgorithm.
+// Points of different groups must not be merged, see WithGroupBy.
+type Algorithm interface {
+	// Cluster returns positions of the level points, merged into the same clusters, in the order of creation.
+	// Each point is in exactly one group, the first point of the group is its seed, e.g. the first one
+	// of the same priority, see CentroidHighestPriority. Groups of a single point, or smaller than MinPoints,
+	// remain at the zoom level as individual points.
+	Cluster(level Level) [][]int
+}
+
+// Level is the zoom level, clustered by Algorithm.
+// Points and the tree must not be modified.
+type Level struct {
+	// Zoom is the zoom level of the clusters
+	Zoom int
+	// Points are points and clusters of the zoom level below in world coordinates of the projection, see Projection
+	Points []*Point
+	// Order is positions of the points in the order they should seed clusters, or nil for the order of points,
+	// see WithWeightedSeeds
+	Order []int
+	// Radius is the clustering radius of the zoom level in world coordinates
+	Radius float64
+	// MinPoints is the minimum number of points to form a cluster
+	MinPoints int
+	// Tree is the KD-tree of the points, Within returns their positions
+	Tree *kdbush.KDBush
+}
+
+// Greedy merges into each seed all not yet merged points within the radius, visiting points in the seed order.
+// It is the default algorithm, the only one updating clusters incrementally and clustering in parallel,
+// see WithParallelism.
+type Greedy struct{}
+
+// Cluster implements Algorithm.
+func (Greedy) Cluster(level Level) [][]int {
+	var groups [][]int
+	// each point is in one group, so groups share the positions slice, which is never reallocated
+	positions := make([]int, 0, len(level.Points))
+	visited := make([]bool, len(level.Points))
+
+	for i := range level.Points {
+		if level.Order != nil {
+			i = level.Order[i]
+		}
+
+		if visited[i] {
+			continue
+		}
+
+		visited[i] = true
+		p := level.Points[i]
+		start := len(positions)
+		positions = append(positions, i)
+
+		for _, j := range level.Tree.Within(p, level.Radius) {
+			// filter out neighbours, that are processed already (and processed point "p" as well)
+			if !visited[j] && level.Points[j].Group == p.Group {
+				visited[j] = true
+				positions = append(positions, j)
+			}
+		}
+
+		groups = append(groups, positions[start:len(positions):len(positions)])
+	}
+
+	return groups
+}
+
+// Grid merges points in the same cell of the grid of twice the radius size, aligned with tiles.
+// It is very fast, and points are never moved to other clusters by their neighbours,
+// but close points in the neighbour cells are not merged.
+type Grid struct{}
+
+type gridCell struct {
+	x, y  int64
+	group string
+}
+
+// Cluster implements Algorithm.
+func (Grid) Cluster(level Level) [][]int {
+	var groups [][]int
+
+	size := 2 * level.Radius
+	cells := make(map[gridCell]int)
+
+	for i := range level.Points {
+		if level.Order != nil {
+			i = level.Order[i]
+		}
+
+		p := level.Points[i]
+		cell := gridCell{int64(math.Floor(p.X / size)), int64(math.Floor(p.Y / size)), p.Group}
+
+		g, ok := cells[cell]
+		if !ok {
+			g = len(groups)
+			cells[cell] = g
+			groups = append(groups, nil)
+		}
+
+		groups[g] = append(groups[g], i)
+	}
+
+	return groups
+}
+
+// DBSCAN merges density-connected points, see https://en.wikipedia.org/wiki/DBSCAN.
+// Point is a core point, if there are at least MinPoints points within the radius of it, including the point itself.
+// Clusters are chains of core points within the radius of each other, with points within the radius of them.
+// Other points remain unclustered, so clusters could be larger than the radius, but have no outliers.
+type DBSCAN struct {
+	// MinPoints is the minimum number of points around the core point, Cluster.MinPoints is used if not positive
+	MinPoints int
+}
+
+// Cluster implements Algorithm.
+func (d DBSCAN) Cluster(level Level) [][]int {
+	minPoints := d.MinPoints
+	if minPoints <= 0 {
+		minPoints = level.MinPoints
+	}
+	// neighbours returns positions of the points of the same group within the radius, if the point is a core one
+	neighbours := func(i int) ([]int, bool) {
+		p := level.Points[i]
+		numPoints := 0
+
+		var result []int
+
+		for _, j := range level.Tree.Within(p, level.Radius) {
+			if b := level.Points[j]; b.Group == p.Group {
+				numPoints += b.NumPoints
+				result = append(result, j)
+			}
+		}
+
+		return result, numPoints >= minPoints
+	}
+
+	var groups [][]int
+
+	merged := make([]bool, len(level.Points))
+
+	for i := range level.Points {
+		if level.Order != nil {
+			i = level.Order[i]
+		}
+
+		if merged[i] {
+			continue
+		}
+
+		queue, core := neighbours(i)
+		if !core {
+			// the point could be merged later into the cluster of a core neighbour
+			continue
+		}
+
+		merged[i] = true
+		group := []int{i}
+
+		for len(queue) > 0 {
+			j := queue[0]
+			queue = queue[1:]
+
+			if merged[j] {
+				continue
+			}
+
+			merged[j] = true
+			group = append(group, j)
+
+			if next, core := neighbours(j); core {
+				queue = append(queue, next...)
+			}
+		}
+
+		groups = append(groups, group)
+	}
+
+	for i := range level.Points {
+		if level.Order != nil {
+			i = level.Order[i]
+		}
+
+		if !merged[i] {
+			groups = append(groups, []int{i})
+		}
+	}
+
+	return groups
+}
+
+// clusterGroups merges points of the zoom level into groups with the algorithm.
+func (c *Cluster) clusterGroups(points []*Point, zoom int, tree *kdbush.KDBush) [][]int {
+	return c.algorithm.Cluster(Level{
+		Zoom:      zoom,
+		Points:    points,
+		Order:     c.seedOrder(points),
+		Radius:    c.worldRadius(zoom),
+		MinPoints: c.MinPoints,
+		Tree:      tree,
+	})
+}
+
+// incremental checks if clusters could be updated locally around the changed points, which is true
+// for the greedy algorithm only. Other algorithms rebuild all zoom levels on updates.
+func (c *Cluster) incremental() bool {
+	_, ok := c.algorithm.(Greedy)
+
+	return ok
+}
@@ -0,0 +1,174 @@
+package cluster_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAlgorithm(t *testing.T) {
+	_, err := cluster.New(nil, cluster.WithAlgorithm(nil))
+	assert.Equal(t, cluster.ErrInvalidAlgorithm, err)
+
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	greedy, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	explicit, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16), cluster.WithAlgorithm(cluster.Greedy{}))
+	require.NoError(t, err)
+	assertSameIndexes(t, greedy, explicit)
+
+	for _, algorithm := range []cluster.Algorithm{cluster.Grid{}, cluster.DBSCAN{MinPoints: 3}} {
+		opts := []cluster.Option{cluster.WithinZoom(0, 16), cluster.WithAlgorithm(algorithm)}
+
+		c, err := cluster.New(geoPoints, opts...)
+		require.NoError(t, err)
+		assertHierarchy(t, c, len(geoPoints))
+		assert.Less(t, len(c.AllClusters(0, -1)), len(geoPoints))
+
+		// parallel build falls back to the serial one
+		parallel, err := cluster.New(geoPoints, append(opts, cluster.WithParallelism(4))...)
+		require.NoError(t, err)
+		assertSameIndexes(t, c, parallel)
+
+		// updates rebuild the same clusters as New does
+		updated, err := cluster.New(geoPoints[:100], opts...)
+		require.NoError(t, err)
+
+		updated.Insert(geoPoints[100:]...)
+		assertSameIndexes(t, c, updated)
+
+		updated.Remove(3, 4)
+		assertHierarchy(t, updated, len(geoPoints)-2)
+
+		var buf bytes.Buffer
+
+		_, err = updated.WriteTo(&buf)
+		require.NoError(t, err)
+
+		snapshot := buf.Bytes()
+
+		_, err = cluster.Load(bytes.NewReader(snapshot), updated.Points, cluster.WithAlgorithm(cluster.Greedy{}))
+		assert.Equal(t, cluster.ErrSnapshotAlgorithmMismatch, err)
+
+		// algorithm is restored from the snapshot, updates of the cluster modify its points,
+		// so the loaded cluster gets a copy of them
+		loaded, err := cluster.Load(bytes.NewReader(snapshot), append([]cluster.GeoPoint(nil), updated.Points...))
+		require.NoError(t, err)
+		assertSameIndexes(t, updated, loaded)
+
+		updated.Remove(5, 6)
+		loaded.Remove(5, 6)
+		assertSameIndexes(t, updated, loaded)
+	}
+}
+
+// unclustered is a custom algorithm, leaving all points unmerged.
+type unclustered struct{}
+
+func (unclustered) Cluster(level cluster.Level) [][]int {
+	groups := make([][]int, len(level.Points))
+	for i := range groups {
+		groups[i] = []int{i}
+	}
+
+	return groups
+}
+
+func TestWithAlgorithm_Custom(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 5), cluster.WithAlgorithm(unclustered{}))
+	require.NoError(t, err)
+	assert.Len(t, c.AllClusters(0, -1), len(geoPoints))
+
+	var buf bytes.Buffer
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	snapshot := buf.Bytes()
+
+	// custom algorithm can't be restored without the option
+	_, err = cluster.Load(bytes.NewReader(snapshot), geoPoints)
+	assert.Equal(t, cluster.ErrSnapshotAlgorithmMismatch, err)
+
+	_, err = cluster.Load(bytes.NewReader(snapshot), geoPoints, cluster.WithAlgorithm(cluster.Greedy{}))
+	assert.Equal(t, cluster.ErrSnapshotAlgorithmMismatch, err)
+
+	loaded, err := cluster.Load(bytes.NewReader(snapshot), geoPoints, cluster.WithAlgorithm(unclustered{}))
+	require.NoError(t, err)
+	assertSameIndexes(t, c, loaded)
+}
+
+func TestGrid(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16), cluster.WithAlgorithm(cluster.Grid{}))
+	require.NoError(t, err)
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		size := 2 * c.Radius(z) / float64(c.TileSize<<uint(z))
+
+		for _, p := range c.AllClusters(z, -1) {
+			if !p.IsCluster(c) {
+				continue
+			}
+
+			children, err := c.GetChildren(p.ID)
+			require.NoError(t, err)
+
+			x, y := cluster.MercatorProjection(cluster.GeoCoordinates{Lng: children[0].X, Lat: children[0].Y})
+
+			for _, child := range children[1:] {
+				cx, cy := cluster.MercatorProjection(cluster.GeoCoordinates{Lng: child.X, Lat: child.Y})
+				require.Equal(t, math.Floor(x/size), math.Floor(cx/size), "cluster %d at zoom %d", p.ID, z)
+				require.Equal(t, math.Floor(y/size), math.Floor(cy/size), "cluster %d at zoom %d", p.ID, z)
+			}
+		}
+	}
+}
+
+func TestDBSCAN(t *testing.T) {
+	// a chain of points within the radius of each other and a point far away from them
+	points := []cluster.GeoPoint{
+		simplePoint{0, 0, 0},
+		simplePoint{1, 1, 0},
+		simplePoint{2, 2, 0},
+		simplePoint{3, 3, 0},
+		simplePoint{4, 4, 0},
+		simplePoint{5, 60, 0},
+	}
+
+	c, err := cluster.New(points, cluster.WithinZoom(3, 3), cluster.WithPointSize(12),
+		cluster.WithAlgorithm(cluster.DBSCAN{MinPoints: 3}))
+	require.NoError(t, err)
+
+	clusters := c.AllClusters(3, -1)
+	require.Len(t, clusters, 2)
+
+	for _, p := range clusters {
+		if p.IsCluster(c) {
+			assert.Equal(t, 5, p.NumPoints)
+		} else {
+			assert.Equal(t, int64(5), p.ID)
+		}
+	}
+	// greedy algorithm splits the chain
+	c, err = cluster.New(points, cluster.WithinZoom(3, 3), cluster.WithPointSize(12))
+	require.NoError(t, err)
+	assert.Greater(t, len(c.AllClusters(3, -1)), 2)
+
+	// no core points
+	c, err = cluster.New(points, cluster.WithinZoom(3, 3), cluster.WithPointSize(12),
+		cluster.WithAlgorithm(cluster.DBSCAN{MinPoints: 4}))
+	require.NoError(t, err)
+
+	clusters = c.AllClusters(3, -1)
+	require.Len(t, clusters, len(points))
+}
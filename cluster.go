@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"math"
+	"sort"
+	"sync"
 
 	"github.com/electrious-go/kdbush"
 )
@@ -14,7 +16,14 @@ const (
 )
 
 var (
-	ErrInvalidCoordinates = errors.New("invalid NW or SE coordinates")
+	ErrInvalidCoordinates      = errors.New("invalid NW or SE coordinates")
+	ErrInvalidAggregator       = errors.New("both map and reduce functions must be provided")
+	ErrClusterNotFound         = errors.New("cluster not found")
+	ErrInvalidRadiusFunc       = errors.New("radius function must be provided")
+	ErrInvalidGroupFunc        = errors.New("group function must be provided")
+	ErrInvalidCentroidStrategy = errors.New("unknown centroid strategy")
+	ErrInvalidAlgorithm        = errors.New("clustering algorithm must be provided")
+	ErrInvalidProjection       = errors.New("projection must be provided")
 )
 
 // Cluster struct get a list or stream of geo objects
@@ -31,11 +40,58 @@ type Cluster struct {
 	TileSize int
 	// NodeSize is size of the KD-tree node, 64 by default. Higher means faster indexing but slower search, and vise versa.
 	NodeSize int
-	// Indexes keeps all KDBush trees
+	// MinPoints is the minimum number of points to form a cluster, 2 by default
+	MinPoints int
+	// Indexes keeps all KDBush trees.
+	// Incremental updates append inserted points to Points of the trees and replace removed points with nil,
+	// until the tree of the zoom level is rebuilt, see Insert
 	Indexes []*kdbush.KDBush
 	// Points keeps original slice of given points
-	Points         []GeoPoint
-	clusterIdxSeed int
+	Points       []GeoPoint
+	mapFn        MapFunc
+	typedMapFn   interface{}
+	reduceFn     ReduceFunc
+	radiusFn     RadiusFunc
+	groupFn      GroupFunc
+	typedGroupFn interface{}
+	parallelism  int
+	stableIDs    bool
+	// weightedSeeds is set, when points of higher weight seed clusters first
+	weightedSeeds bool
+	centroid      CentroidStrategy
+	algorithm     Algorithm
+	projection    Projection
+	// clusters keeps slots of clusters at the zoom levels they are created at by cluster IDs,
+	// when IDs don't encode slots, after incremental updates or with stable IDs
+	clusters       map[int64]int
+	nextClusterIdx []int
+	// clusterLevels keeps clusters, created at each zoom level, with their children by zoom level
+	clusterLevels []levelClusters
+	// indexed keeps the number of points of each zoom level, indexed by its KD-tree, and pending the number of points,
+	// inserted into the zoom level or removed from it by incremental updates since the KD-tree was built
+	indexed []int
+	pending []int
+	// numLeaves, leaf, leafID, leafGroup, mapLeaf, leafWeight and leafPriority give access to the original points
+	// by their positions, which are either GeoPoints of Points slice, or points of TypedCluster.
+	// leafGroup is nil, unless points are grouped, see WithGroupBy
+	numLeaves    func() int
+	leaf         func(i int) GeoPoint
+	leafID       func(i int) int64
+	leafGroup    func(i int) string
+	mapLeaf      func(i int) interface{}
+	leafWeight   func(i int) float64
+	leafPriority func(i int) float64
+	// leavesCopied is set, when the original points are copied before the first incremental update
+	leavesCopied bool
+	// leafPoints keeps the original points of the lowest level by their positions, leafPositions keeps the last
+	// position of the original points by their GeoPoint IDs and samePositions the previous position of the same ID,
+	// or -1, so Remove finds the points without scanning all of them
+	leafPoints    []*Point
+	leafPositions map[int64]int
+	samePositions []int
+	// hulls caches convex hulls of the clusters by their IDs, see GetClusterHull
+	hulls   map[int64][]kdbush.SimplePoint
+	hullsMu sync.Mutex
 }
 
 // New create new Cluster instance with default params.
@@ -44,12 +100,32 @@ type Cluster struct {
 // They are not copied in favor of memory efficiency.
 // GetCoordinates called only once for each object. Can be recalculated on the fly, if needed.
 func New(points []GeoPoint, opts ...Option) (*Cluster, error) {
+	cluster, err := configure(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster.Points = points
+	if err := cluster.useGeoPoints(); err != nil {
+		return nil, err
+	}
+
+	cluster.build(translatePoints(points, 0, geoPointCoordinates, cluster.projection, cluster.mapLeaf))
+
+	return cluster, nil
+}
+
+// configure creates a cluster with default params, modified by options.
+func configure(opts []Option) (*Cluster, error) {
 	cluster := &Cluster{
-		MinZoom:   0,
-		MaxZoom:   21,
-		PointSize: 40, // 240
-		TileSize:  512,
-		NodeSize:  64,
+		MinZoom:    0,
+		MaxZoom:    21,
+		PointSize:  40, // 240
+		TileSize:   512,
+		NodeSize:   64,
+		MinPoints:  2,
+		algorithm:  Greedy{},
+		projection: WebMercator{},
 	}
 
 	for _, opt := range opts {
@@ -64,23 +140,87 @@ func New(points []GeoPoint, opts ...Option) (*Cluster, error) {
 	// cluster.MaxZoom--
 	// adding extra layer for infinite zoom (initial) layers data storage
 	cluster.Indexes = make([]*kdbush.KDBush, cluster.MaxZoom-cluster.MinZoom+2)
-	cluster.Points = points
-	// get digits number, start from next exponent
-	// if we have 78, all cluster will start from 100...
-	// if we have 986 points, all clusters ids will start from 1000
-	cluster.clusterIdxSeed = int(math.Pow(10, float64(digitsCount(len(points)))))
-	clusters := translateGeoPointsToPoints(points)
 
-	for z := cluster.MaxZoom; z >= cluster.MinZoom; z-- {
-		// create index from clusters from previous iteration
-		cluster.Indexes[z+1-cluster.MinZoom] = kdbush.NewBush(clustersToPoints(clusters), cluster.NodeSize)
-		// create clusters for level up using just created index
-		clusters = cluster.clusterize(clusters, z)
+	return cluster, nil
+}
+
+// useGeoPoints sets access to the original points of Points slice.
+// Returns ErrInvalidAggregator or ErrInvalidGroupFunc if aggregator or group function of other points type is provided.
+func (c *Cluster) useGeoPoints() error {
+	c.numLeaves = func() int {
+		return len(c.Points)
+	}
+	c.leaf = func(i int) GeoPoint {
+		return c.Points[i]
+	}
+	c.leafID = func(i int) int64 {
+		return c.Points[i].GetID()
+	}
+	c.leafWeight = func(i int) float64 {
+		return weightOf(c.Points[i])
+	}
+	c.leafPriority = func(i int) float64 {
+		return priorityOf(c.Points[i])
 	}
-	// index topmost points
-	cluster.Indexes[0] = kdbush.NewBush(clustersToPoints(clusters), cluster.NodeSize)
 
-	return cluster, nil
+	mapFn := c.mapFn
+
+	if c.typedMapFn != nil {
+		typed, ok := c.typedMapFn.(func(p GeoPoint) interface{})
+		if !ok {
+			return ErrInvalidAggregator
+		}
+
+		mapFn = typed
+	}
+
+	if mapFn != nil {
+		c.mapLeaf = func(i int) interface{} {
+			return mapFn(c.Points[i])
+		}
+	}
+
+	groupFn := c.groupFn
+
+	if c.typedGroupFn != nil {
+		typed, ok := c.typedGroupFn.(func(p GeoPoint) string)
+		if !ok {
+			return ErrInvalidGroupFunc
+		}
+
+		groupFn = typed
+	}
+
+	if groupFn != nil {
+		c.leafGroup = func(i int) string {
+			return groupFn(c.Points[i])
+		}
+	}
+
+	return nil
+}
+
+// build creates indexes of all zoom levels from the leaves.
+func (c *Cluster) build(clusters []*Point) {
+	if c.stableIDs {
+		c.clusters = make(map[int64]int)
+	}
+
+	c.clusterLevels = make([]levelClusters, c.MaxZoom-c.MinZoom+1)
+	c.initLeaves(clusters)
+
+	if c.parallelism > 1 && c.incremental() {
+		c.buildParallel(clusters)
+	} else {
+		for z := c.MaxZoom; z >= c.MinZoom; z-- {
+			// create index from clusters from previous iteration
+			c.Indexes[z+1-c.MinZoom] = kdbush.NewBush(clustersToPoints(clusters), c.NodeSize)
+			// create clusters for level up using just created index
+			clusters = c.clusterize(clusters, z)
+		}
+		// index topmost points
+		c.Indexes[0] = kdbush.NewBush(clustersToPoints(clusters), c.NodeSize)
+	}
 }
 
 // GetClusters returns the array of clusters for zoom level.
@@ -88,10 +228,10 @@ func New(points []GeoPoint, opts ...Option) (*Cluster, error) {
 // northWest is left topmost point, southEast is right bottom point.
 // returns the array of clustered points,
 // X coordinate of returned object is Longitude and Y coordinate of returned object is Latitude.
-func (c *Cluster) GetClusters(northWest, southEast GeoPoint, zoom int, limit int) ([]Point, error) {
+func (c *Cluster) GetClusters(northWest, southEast GeoPoint, zoom int, limit int, groups ...string) ([]Point, error) {
 	// According to benchmarks, implementation without a context is only 75 ns/op faster,
 	// not worth it to duplicate the functions.
-	return c.GetClustersWithContext(context.TODO(), northWest, southEast, zoom, limit)
+	return c.GetClustersWithContext(context.TODO(), northWest, southEast, zoom, limit, groups...)
 }
 
 // GetClustersWithContext returns the array of clusters for zoom level.
@@ -99,18 +239,74 @@ func (c *Cluster) GetClusters(northWest, southEast GeoPoint, zoom int, limit int
 // northWest is left topmost point, southEast is right bottom point.
 // returns the array of clustered points,
 // X coordinate of returned object is Longitude and Y coordinate of returned object is Latitude.
+// If groups are provided, only points and clusters of these groups are returned, see WithGroupBy.
 // Returns error when context is closed or provided NW or SE geo points are invalid.
-func (c *Cluster) GetClustersWithContext(ctx context.Context, northWest, southEast GeoPoint, zoom, limit int) ([]Point, error) {
+func (c *Cluster) GetClustersWithContext(ctx context.Context, northWest, southEast GeoPoint, zoom, limit int,
+	groups ...string,
+) ([]Point, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
+	boxes, err := c.worldBoxes(northWest, southEast)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(boxes) > 1 {
+		var result []Point
+
+		for _, box := range boxes {
+			points, err := c.getClusters(ctx, box, zoom, limit, groups)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, points...)
+		}
+
+		return result, nil
+	}
+
+	return c.getClusters(ctx, boxes[0], zoom, limit, groups)
+}
+
+// worldBox is a rectangle in world coordinates of the projection, X grows to the east and Y to the south.
+type worldBox struct {
+	minX, minY, maxX, maxY float64
+}
+
+// extend grows the box to contain the other box.
+func (b *worldBox) extend(other worldBox) {
+	b.minX = math.Min(b.minX, other.minX)
+	b.minY = math.Min(b.minY, other.minY)
+	b.maxX = math.Max(b.maxX, other.maxX)
+	b.maxY = math.Max(b.maxY, other.maxY)
+}
+
+// worldBoxes returns the area between northWest and southEast corners in world coordinates of the projection.
+// Area, crossing the antimeridian, is split into eastern and western boxes, unless the world of the projection
+// doesn't wrap, see WrappingProjection.
+// Returns ErrInvalidCoordinates if any of the corners has no coordinates.
+func (c *Cluster) worldBoxes(northWest, southEast GeoPoint) ([]worldBox, error) {
 	nw := northWest.GetCoordinates()
 	se := southEast.GetCoordinates()
 
 	if nw == nil || se == nil {
 		return nil, ErrInvalidCoordinates
 	}
+
+	if !c.wraps() {
+		nwX, nwY := c.projection.Project(*nw)
+		seX, seY := c.projection.Project(*se)
+
+		return []worldBox{{
+			minX: math.Min(nwX, seX),
+			minY: math.Min(nwY, seY),
+			maxX: math.Max(nwX, seX),
+			maxY: math.Max(nwY, seY),
+		}}, nil
+	}
 	// Original mapbox/supercluster library code has the following expression to calculate min and max longitudes:
 	// let minLng = ((bbox[0] + 180) % 360 + 360) % 360 - 180;
 	// Mozilla developer guide suggests such construction to obtain a modulo
@@ -129,28 +325,27 @@ func (c *Cluster) GetClustersWithContext(ctx context.Context, northWest, southEa
 
 	maxLat := math.Max(-90, math.Min(90, nw.Lat))
 
-	if se.Lng-nw.Lng >= 360 {
-		minLng = -180
-		maxLng = 180
-	} else if minLng > maxLng {
-		easternHem, err := c.GetClusters(&Point{X: minLng, Y: maxLat}, &Point{X: 180, Y: minLat}, zoom, limit)
-		if err != nil {
-			return nil, err
-		}
+	box := func(minLng, maxLng float64) worldBox {
+		nwX, nwY := c.projection.Project(GeoCoordinates{Lng: minLng, Lat: maxLat})
+		seX, seY := c.projection.Project(GeoCoordinates{Lng: maxLng, Lat: minLat})
 
-		westernHem, err := c.GetClusters(&Point{X: -180, Y: maxLat}, &Point{X: maxLng, Y: minLat}, zoom, limit)
-		if err != nil {
-			return nil, err
-		}
+		return worldBox{minX: nwX, minY: nwY, maxX: seX, maxY: seY}
+	}
 
-		return append(easternHem, westernHem...), nil
+	if se.Lng-nw.Lng >= 360 {
+		return []worldBox{box(-180, 180)}, nil
+	} else if minLng > maxLng {
+		return []worldBox{box(minLng, 180), box(-180, maxLng)}, nil
 	}
 
-	zoom = c.LimitZoom(zoom) - c.MinZoom
-	index := c.Indexes[zoom]
-	nwX, nwY := MercatorProjection(GeoCoordinates{Lng: minLng, Lat: maxLat})
-	seX, seY := MercatorProjection(GeoCoordinates{Lng: maxLng, Lat: minLat})
-	ids := index.Range(nwX, nwY, seX, seY)
+	return []worldBox{box(minLng, maxLng)}, nil
+}
+
+// getClusters returns clusters of the groups at the zoom level within the box, limited by limit if it's positive.
+func (c *Cluster) getClusters(ctx context.Context, box worldBox, zoom, limit int, groups []string) ([]Point, error) {
+	level := c.LimitZoom(zoom) - c.MinZoom
+	index := c.Indexes[level]
+	ids := inGroups(c.rangeLevel(level, box), index.Points, groups)
 
 	if (limit > 0) && (len(ids) > limit) {
 		ids = ids[:limit]
@@ -163,72 +358,352 @@ func (c *Cluster) GetClustersWithContext(ctx context.Context, northWest, southEa
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
-			p := index.Points[ids[i]].(*Point)
-			cp := *p
-			coordinates := ReverseMercatorProjection(cp.X, cp.Y)
-			cp.X = coordinates.Lng
-			cp.Y = coordinates.Lat
-			result[i] = cp
+			result[i] = c.geoPoint(index.Points[ids[i]].(*Point))
 		}
 	}
 
 	return result, nil
 }
 
+// inGroups filters positions of the points, keeping points of the groups. All positions are kept if groups are empty.
+func inGroups(ids []int, points []kdbush.Point, groups []string) []int {
+	if len(groups) == 0 {
+		return ids
+	}
+
+	filtered := ids[:0]
+
+	for _, i := range ids {
+		group := points[i].(*Point).Group
+		for _, g := range groups {
+			if group == g {
+				filtered = append(filtered, i)
+
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// geoPoint returns copy of the point with longitude as X coordinate and latitude as Y coordinate.
+func (c *Cluster) geoPoint(p *Point) Point {
+	cp := *p
+	coordinates := c.projection.Unproject(cp.X, cp.Y)
+	cp.X = coordinates.Lng
+	cp.Y = coordinates.Lat
+
+	return cp
+}
+
+// GetChildren returns clusters and points, merged into the cluster one zoom level below the cluster zoom.
+// X coordinate of returned object is Longitude and Y coordinate is Latitude.
+// Returns ErrClusterNotFound if clusterID doesn't belong to any cluster.
+func (c *Cluster) GetChildren(clusterID int64) ([]Point, error) {
+	children, err := c.getChildren(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Point, len(children))
+
+	for i := range children {
+		result[i] = c.geoPoint(children[i])
+	}
+
+	return result, nil
+}
+
 // GetClustersPointsInRadius will return child points for specific cluster
-// this is done with kdbush.Within method allowing fast search.
-func (c *Cluster) GetClustersPointsInRadius(clusterID int) []*Point {
-	// if clusterID is smaller than initial seed
-	// it means that it is original point from which
-	// cluster(s) are made
-	if clusterID < c.clusterIdxSeed {
+// in world coordinates of the projection, nil is returned for unknown cluster.
+//
+// Deprecated: use GetChildren instead.
+func (c *Cluster) GetClustersPointsInRadius(clusterID int64) []*Point {
+	children, err := c.getChildren(clusterID)
+	if err != nil {
 		return nil
 	}
 
-	originIndex := (clusterID >> 5) - c.clusterIdxSeed
-	originZoom := (clusterID % 32) - 1
-	originTree := c.Indexes[originZoom]
-	originPoint := originTree.Points[originIndex]
-	r := float64(c.PointSize) / float64(c.TileSize*(1<<uint(originZoom)))
-	treeBelow := c.Indexes[originZoom+1-c.MinZoom]
-	ids := treeBelow.Within(originPoint, r)
+	return append([]*Point(nil), children...)
+}
+
+// GetLeaves returns original points of the cluster, skipping first offset points.
+// At most limit points are returned, or all of them if limit is not positive.
+// Returns ErrClusterNotFound if clusterID doesn't belong to any cluster.
+func (c *Cluster) GetLeaves(clusterID int64, limit, offset int) ([]GeoPoint, error) {
+	points, err := c.getLeaves(clusterID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
 
-	var children []*Point
+	leaves := make([]GeoPoint, len(points))
+	for i, p := range points {
+		leaves[i] = c.leaf(int(p.ID))
+	}
 
-	for _, i := range ids {
-		children = append(children, treeBelow.Points[i].(*Point))
+	return leaves, nil
+}
+
+// getLeaves returns the original points of the lowest level of the cluster in the clusters hierarchy order.
+// Children before the offset are skipped as a whole, so paging doesn't collect all leaves of the cluster.
+func (c *Cluster) getLeaves(clusterID int64, limit, offset int) ([]*Point, error) {
+	origin, _, err := c.getCluster(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset >= origin.NumPoints {
+		return nil, nil
+	}
+
+	n := origin.NumPoints - offset
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	return c.appendLeaves(make([]*Point, 0, n), origin, offset, n), nil
+}
+
+// appendLeaves appends the original points of the point, skipping first offset ones, until leaves has n points.
+func (c *Cluster) appendLeaves(leaves []*Point, p *Point, offset, n int) []*Point {
+	if p.ID < clusterFlag {
+		return append(leaves, p)
+	}
+
+	for _, child := range c.children(p) {
+		if len(leaves) == n {
+			break
+		}
+
+		if offset >= child.NumPoints {
+			offset -= child.NumPoints
+
+			continue
+		}
+
+		leaves = c.appendLeaves(leaves, child, offset, n)
+		offset = 0
+	}
+
+	return leaves
+}
+
+// levelClusters keeps clusters, created at the zoom level, by their slots.
+// Slots are the indexes of the clusters at the zoom level in the order of creation,
+// which are encoded in their IDs, unless IDs are stable or clusters are updated, see Cluster.clusters.
+type levelClusters struct {
+	// points are the clusters, nil for clusters, dissolved by incremental updates
+	points []*Point
+	// children keeps children of all clusters, children of the cluster end at ends of its slot
+	children []*Point
+	ends     []int
+	// boxes contain all original points of the clusters
+	boxes []worldBox
+	// centroids are kept for other centroid strategies than CentroidWeightedMean only
+	centroids []centroid
+}
+
+// reserve adds the slot for the cluster of n children, which are set later with set.
+func (l *levelClusters) reserve(n int) int {
+	slot := len(l.points)
+	l.points = append(l.points, nil)
+	l.children = append(l.children, make([]*Point, n)...)
+	l.ends = append(l.ends, len(l.children))
+	l.boxes = append(l.boxes, worldBox{})
+
+	return slot
+}
+
+// childrenOf returns children of the cluster in the slot, sharing the children slice of the zoom level.
+func (l *levelClusters) childrenOf(slot int) []*Point {
+	start := 0
+	if slot > 0 {
+		start = l.ends[slot-1]
+	}
+
+	return l.children[start:l.ends[slot]:l.ends[slot]]
+}
+
+// children returns children of the cluster, sharing the children slice of its zoom level.
+func (c *Cluster) children(cluster *Point) []*Point {
+	zoom, slot, ok := c.clusterSlot(cluster.ID)
+	if !ok {
+		return nil
+	}
+
+	return c.clusterLevels[zoom-c.MinZoom].childrenOf(slot)
+}
+
+// getChildren returns clusters and points, merged into the cluster one zoom level below its origin zoom.
+func (c *Cluster) getChildren(clusterID int64) ([]*Point, error) {
+	origin, _, err := c.getCluster(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.children(origin), nil
+}
+
+// getCluster finds the cluster by its ID and returns it with the zoom level the cluster was created at.
+func (c *Cluster) getCluster(clusterID int64) (*Point, int, error) {
+	originZoom, slot, ok := c.clusterSlot(clusterID)
+	if !ok {
+		return nil, 0, ErrClusterNotFound
+	}
+
+	origin := c.clusterLevels[originZoom-c.MinZoom].points[slot]
+	if origin == nil || origin.ID != clusterID {
+		return nil, 0, ErrClusterNotFound
 	}
 
-	return children
+	return origin, originZoom, nil
+}
+
+// clusterSlot returns the zoom level the cluster is created at and slot of the cluster at that zoom level.
+// Slot is the index encoded in the ID, unless slots are collected in clusters.
+func (c *Cluster) clusterSlot(clusterID int64) (zoom, slot int, ok bool) {
+	zoom, slot, isCluster := DecodeClusterID(clusterID)
+	if !isCluster || clusterID&filteredFlag != 0 || zoom < c.MinZoom || zoom > c.MaxZoom {
+		return 0, 0, false
+	}
+
+	if c.clusters != nil {
+		if slot, ok = c.clusters[clusterID]; !ok {
+			return 0, 0, false
+		}
+	}
+
+	if slot < 0 || slot >= len(c.clusterLevels[zoom-c.MinZoom].points) {
+		return 0, 0, false
+	}
+
+	return zoom, slot, true
+}
+
+// indexClusters collects slots of all clusters by their IDs.
+func (c *Cluster) indexClusters() {
+	c.clusters = make(map[int64]int, len(c.clusters))
+
+	for _, level := range c.clusterLevels {
+		for slot, p := range level.points {
+			if p != nil {
+				c.clusters[p.ID] = slot
+			}
+		}
+	}
+}
+
+// boundClusters calculates boxes of all clusters, read from the snapshot, from boxes of their children,
+// from the original points to the top zoom level. Boxes of the built clusters are set, when they are created.
+func (c *Cluster) boundClusters() {
+	for level := len(c.clusterLevels) - 1; level >= 0; level-- {
+		c.clusterLevels[level].boxes = make([]worldBox, len(c.clusterLevels[level].points))
+
+		for slot := range c.clusterLevels[level].boxes {
+			c.boundCluster(level, slot)
+		}
+	}
+}
+
+// boundCluster calculates the box of the cluster in the slot of the level from boxes of its children,
+// which are either the original points, or clusters with boxes.
+func (c *Cluster) boundCluster(level, slot int) {
+	box := worldBox{minX: math.Inf(1), minY: math.Inf(1), maxX: math.Inf(-1), maxY: math.Inf(-1)}
+
+	for _, child := range c.clusterLevels[level].childrenOf(slot) {
+		box.extend(c.extent(child))
+	}
+
+	c.clusterLevels[level].boxes[slot] = box
+}
+
+// extent returns the box, containing all original points of the cluster and the cluster itself, or the point itself.
+func (c *Cluster) extent(p *Point) worldBox {
+	if zoom, slot, ok := c.clusterSlot(p.ID); ok {
+		box := c.clusterLevels[zoom-c.MinZoom].boxes[slot]
+		// the mean of the points could be out of their box by a rounding error
+		box.extend(worldBox{minX: p.X, minY: p.Y, maxX: p.X, maxY: p.Y})
+
+		return box
+	}
+
+	return worldBox{minX: p.X, minY: p.Y, maxX: p.X, maxY: p.Y}
+}
+
+// GetClusterBounds returns the rectangle, containing all original points of the cluster,
+// e.g. to fit the map view to the cluster.
+// Returns ErrClusterNotFound if there is no cluster with the ID.
+func (c *Cluster) GetClusterBounds(clusterID int64) (Bounds, error) {
+	origin, _, err := c.getCluster(clusterID)
+	if err != nil {
+		return Bounds{}, err
+	}
+
+	return c.bounds(c.extent(origin)), nil
+}
+
+// GetBounds returns the rectangle, containing all original points, e.g. to fit the map view to the data.
+// Bounds of the whole world of the projection are returned for the cluster without points.
+func (c *Cluster) GetBounds() Bounds {
+	box := worldBox{minX: math.Inf(1), minY: math.Inf(1), maxX: math.Inf(-1), maxY: math.Inf(-1)}
+	// clusters of the top zoom level contain all points
+	for _, kp := range c.Indexes[0].Points {
+		if p := kp.(*Point); p != nil {
+			box.extend(c.extent(p))
+		}
+	}
+
+	if box.minX > box.maxX {
+		return c.bounds(worldBox{minX: 0, minY: 0, maxX: 1, maxY: 1})
+	}
+
+	return c.bounds(box)
+}
+
+// bounds returns coordinates of the box in world coordinates.
+func (c *Cluster) bounds(box worldBox) Bounds {
+	northWest := c.projection.Unproject(box.minX, box.minY)
+	southEast := c.projection.Unproject(box.maxX, box.maxY)
+
+	return Bounds{
+		MinLng: math.Min(northWest.Lng, southEast.Lng),
+		MinLat: math.Min(northWest.Lat, southEast.Lat),
+		MaxLng: math.Max(northWest.Lng, southEast.Lng),
+		MaxLat: math.Max(northWest.Lat, southEast.Lat),
+	}
 }
 
 // GetClusterExpansionZoom will return how much you need to zoom to get to a next cluster.
-func (c *Cluster) GetClusterExpansionZoom(clusterID int) int {
-	if clusterID < c.clusterIdxSeed {
+func (c *Cluster) GetClusterExpansionZoom(clusterID int64) int {
+	_, clusterZoom, err := c.getCluster(clusterID)
+	if err != nil {
 		return c.MaxZoom
 	}
 
-	clusterZoom := (clusterID % 32) - 1
 	id := clusterID
 
 	for clusterZoom < c.MaxZoom {
-		children := c.GetClustersPointsInRadius(id)
-		// nil means it is point not cluster
-		if children == nil {
+		children, err := c.getChildren(id)
+		if err != nil {
 			return c.MaxZoom
 		}
 
 		clusterZoom++
-
-		if clusterZoom >= c.MaxZoom+1 {
-			return c.MaxZoom
-		}
 		// in case it's more than 1, then return current zoom
-		if len(children) != 1 {
+		if len(children) != 1 || !children[0].IsCluster(c) {
 			break
 		}
 
 		id = children[0].ID
+		// child cluster could be created at any zoom below
+		if _, clusterZoom, err = c.getCluster(id); err != nil {
+			return c.MaxZoom
+		}
 	}
 
 	return clusterZoom
@@ -237,22 +712,23 @@ func (c *Cluster) GetClusterExpansionZoom(clusterID int) int {
 // AllClusters returns all cluster points, array of Point, for zoom on the map.
 // X coordinate of returned object is Longitude and Y coordinate is Latitude.
 func (c *Cluster) AllClusters(zoom int, limit int) []Point {
-	index := c.Indexes[c.LimitZoom(zoom)-c.MinZoom]
-	points := index.Points
+	points := c.Indexes[c.LimitZoom(zoom)-c.MinZoom].Points
 
-	if (limit > 0) && (len(points) > limit) {
-		points = points[:limit]
+	n := len(points)
+	if limit > 0 && limit < n {
+		n = limit
 	}
 
-	result := make([]Point, len(points))
+	result := make([]Point, 0, n)
+
+	for _, kp := range points {
+		if limit > 0 && len(result) == limit {
+			break
+		}
 
-	for i := range points {
-		p := index.Points[i].(*Point)
-		cp := *p
-		coordinates := ReverseMercatorProjection(cp.X, cp.Y)
-		cp.X = coordinates.Lng
-		cp.Y = coordinates.Lat
-		result[i] = cp
+		if p := kp.(*Point); p != nil {
+			result = append(result, c.geoPoint(p))
+		}
 	}
 
 	return result
@@ -260,65 +736,290 @@ func (c *Cluster) AllClusters(zoom int, limit int) []Point {
 
 // clusterize points for zoom level.
 func (c *Cluster) clusterize(points []*Point, zoom int) []*Point {
-	var result []*Point
-
-	r := float64(c.PointSize) / float64(c.TileSize*(1<<uint(zoom)))
-	index := 0
-	// iterate all clusters
-	for pi := range points {
-		// skip points we have already clustered
-		p := points[pi]
-		if p.zoom <= zoom {
+	result := make([]*Point, 0, len(points))
+
+	for _, group := range c.clusterGroups(points, zoom, c.Indexes[zoom+1-c.MinZoom]) {
+		p := points[group[0]]
+
+		foundNeighbours := make([]*Point, 0, len(group)-1)
+		for _, j := range group[1:] {
+			foundNeighbours = append(foundNeighbours, points[j])
+		}
+		// create new cluster
+		if c.formsCluster(p, foundNeighbours) {
+			result = append(result, c.addCluster(zoom, p, foundNeighbours))
+
 			continue
 		}
-		// mark this point as visited
-		p.zoom = zoom
-		// find all neighbours
-		tree := c.Indexes[zoom+1-c.MinZoom]
-		neighbourIds := tree.Within(&kdbush.SimplePoint{X: p.X, Y: p.Y}, r)
-		nPoints := p.NumPoints
-		wx := p.X * float64(nPoints)
-		wy := p.Y * float64(nPoints)
-
-		var foundNeighbours []*Point
-
-		for j := range neighbourIds {
-			b := points[neighbourIds[j]]
-			// filter out neighbours, that are processed already (and processed point "p" as well)
-			if zoom < b.zoom {
-				wx += b.X * float64(b.NumPoints)
-				wy += b.Y * float64(b.NumPoints)
-				nPoints += b.NumPoints
-				b.zoom = zoom // set the zoom to skip in other iterations
-
-				foundNeighbours = append(foundNeighbours, b)
+		// group is too small to form a cluster, so neighbours remain at the zoom level as well
+		result = append(result, p)
+		result = append(result, foundNeighbours...)
+	}
+
+	return result
+}
+
+// formsCluster checks if the point and its neighbours have at least MinPoints points to be merged into a cluster.
+func (c *Cluster) formsCluster(p *Point, neighbours []*Point) bool {
+	if len(neighbours) == 0 {
+		return false
+	}
+
+	numPoints := p.NumPoints
+	for _, neighbour := range neighbours {
+		numPoints += neighbour.NumPoints
+	}
+
+	return numPoints >= c.MinPoints
+}
+
+// seedOrder returns positions of the points in the order they seed clusters, by descending weight,
+// or nil if points seed clusters in their order.
+func (c *Cluster) seedOrder(points []*Point) []int {
+	if !c.weightedSeeds {
+		return nil
+	}
+
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return points[order[i]].Weight > points[order[j]].Weight
+	})
+
+	return order
+}
+
+// newCluster merges the point with its neighbours into the new cluster.
+// Cluster is positioned in the center of merged points, weighted by their weights,
+// or by their numbers of points if the total weight is zero.
+func (c *Cluster) newCluster(id int64, p *Point, neighbours []*Point) *Point {
+	nPoints := p.NumPoints
+	weight := p.Weight
+	px, py := c.mean(p)
+	wx, wy := px*p.Weight, py*p.Weight
+	nx, ny := px*float64(nPoints), py*float64(nPoints)
+
+	newCluster := &Point{}
+	newCluster.ID = id
+	newCluster.Properties = p.Properties
+	newCluster.hash = p.hash
+	newCluster.Group = p.Group
+	p.parentID = id
+
+	for _, neighbour := range neighbours {
+		newCluster.hash += neighbour.hash
+		mx, my := c.mean(neighbour)
+		wx += mx * neighbour.Weight
+		wy += my * neighbour.Weight
+		weight += neighbour.Weight
+		nx += mx * float64(neighbour.NumPoints)
+		ny += my * float64(neighbour.NumPoints)
+		nPoints += neighbour.NumPoints
+		neighbour.parentID = id
+
+		if c.reduceFn != nil {
+			newCluster.Properties = c.reduceFn(newCluster.Properties, neighbour.Properties)
+		}
+	}
+
+	if weight > 0 {
+		newCluster.X, newCluster.Y = wx/weight, wy/weight
+	} else {
+		newCluster.X, newCluster.Y = nx/float64(nPoints), ny/float64(nPoints)
+	}
+
+	newCluster.NumPoints = nPoints
+	newCluster.Weight = weight
+
+	return newCluster
+}
+
+// centroid keeps the weighted mean of the original points of the cluster, which differs from its position
+// for other centroid strategies, and the highest priority of them, see WithCentroidStrategy.
+type centroid struct {
+	meanX, meanY float64
+	priority     float64
+}
+
+// mean returns the weighted mean of the original points of the point.
+func (c *Cluster) mean(p *Point) (float64, float64) {
+	if c.centroid == CentroidWeightedMean {
+		return p.X, p.Y
+	}
+
+	m := c.centroidOf(p)
+
+	return m.meanX, m.meanY
+}
+
+// centroidOf returns the centroid of the cluster, or of the original point.
+// Centroids of the clusters are kept for other strategies than CentroidWeightedMean only.
+func (c *Cluster) centroidOf(p *Point) centroid {
+	if zoom, slot, ok := c.clusterSlot(p.ID); ok {
+		return c.clusterLevels[zoom-c.MinZoom].centroids[slot]
+	}
+
+	return centroid{meanX: p.X, meanY: p.Y, priority: c.leafPriority(int(p.ID))}
+}
+
+// position returns the position of the cluster of the point and its neighbours with the centroid strategy
+// and the centroid of the cluster, positioned in the weighted mean.
+func (c *Cluster) position(cluster, p *Point, neighbours []*Point) (centroid, float64, float64) {
+	m := centroid{meanX: cluster.X, meanY: cluster.Y, priority: c.centroidOf(p).priority}
+	for _, neighbour := range neighbours {
+		m.priority = math.Max(m.priority, c.centroidOf(neighbour).priority)
+	}
+
+	switch c.centroid {
+	case CentroidMedoid:
+		medoid := p
+		dist := sqDist(p.X, p.Y, m.meanX, m.meanY)
+
+		for _, neighbour := range neighbours {
+			if d := sqDist(neighbour.X, neighbour.Y, m.meanX, m.meanY); d < dist {
+				medoid, dist = neighbour, d
 			}
 		}
 
-		newCluster := p
-		// create new cluster
-		if len(foundNeighbours) > 0 {
-			newCluster = &Point{}
-			newCluster.X = wx / float64(nPoints)
-			newCluster.Y = wy / float64(nPoints)
-			newCluster.NumPoints = nPoints
-			newCluster.zoom = InfinityZoomLevel
-			// create ID based on seed + index
-			// this is then shifted to create space for zoom
-			// this is useful when you need extract zoom from ID
-			newCluster.ID = ((c.clusterIdxSeed + index) << 5) + zoom + 1
-			newCluster.Included = p.Included
-
-			for _, neighbour := range foundNeighbours {
-				newCluster.Included = append(newCluster.Included, neighbour.Included...)
+		return m, medoid.X, medoid.Y
+	case CentroidHighestPriority:
+		if c.centroidOf(p).priority == m.priority {
+			return m, p.X, p.Y
+		}
+
+		for _, neighbour := range neighbours {
+			if c.centroidOf(neighbour).priority == m.priority {
+				return m, neighbour.X, neighbour.Y
 			}
 		}
+	}
+
+	return m, m.meanX, m.meanY
+}
+
+// addCluster merges the point with its neighbours into the new cluster, created at the zoom level,
+// and keeps the cluster with its children in the next slot of the zoom level.
+func (c *Cluster) addCluster(zoom int, p *Point, neighbours []*Point) *Point {
+	id, slot := c.reserveCluster(zoom, p, neighbours)
 
-		result = append(result, newCluster)
-		index++
+	return c.setCluster(zoom, slot, id, p, neighbours)
+}
+
+// reserveCluster returns ID of the cluster of the point and its neighbours, created at the zoom level,
+// and reserves the next slot of the zoom level for it.
+// It must not be called concurrently, while clusters of the reserved slots could be set concurrently.
+func (c *Cluster) reserveCluster(zoom int, p *Point, neighbours []*Point) (int64, int) {
+	level := &c.clusterLevels[zoom-c.MinZoom]
+	slot := level.reserve(len(neighbours) + 1)
+	// index continues indexes of the clusters of the zoom level after incremental updates
+	index := slot
+	if c.nextClusterIdx != nil {
+		index = c.nextClusterIdx[zoom-c.MinZoom]
+		c.nextClusterIdx[zoom-c.MinZoom]++
 	}
 
-	return result
+	id := c.newClusterID(zoom, index, p, neighbours)
+	if c.clusters != nil {
+		c.clusters[id] = slot
+	}
+
+	if c.centroid != CentroidWeightedMean {
+		level.centroids = append(level.centroids, centroid{})
+	}
+
+	return id, slot
+}
+
+// setCluster merges the point with its neighbours into the cluster of the reserved slot of the zoom level.
+func (c *Cluster) setCluster(zoom, slot int, id int64, p *Point, neighbours []*Point) *Point {
+	level := &c.clusterLevels[zoom-c.MinZoom]
+	cluster := c.newCluster(id, p, neighbours)
+	if c.centroid != CentroidWeightedMean {
+		level.centroids[slot], cluster.X, cluster.Y = c.position(cluster, p, neighbours)
+	}
+
+	level.points[slot] = cluster
+
+	children := level.childrenOf(slot)
+	children[0] = p
+	copy(children[1:], neighbours)
+	c.boundCluster(zoom-c.MinZoom, slot)
+
+	return cluster
+}
+
+// newClusterID returns ID of the cluster of the point and its neighbours, created at the zoom level.
+// Index is the index of the cluster among clusters of the zoom level in the order of creation.
+// With stable IDs, the ID is derived from hashes of the cluster members, probing the next IDs on collisions
+// with the IDs in clusters.
+func (c *Cluster) newClusterID(zoom, index int, p *Point, neighbours []*Point) int64 {
+	if !c.stableIDs {
+		return clusterID(zoom, index)
+	}
+
+	hash := p.hash
+	for _, neighbour := range neighbours {
+		hash += neighbour.hash
+	}
+
+	for probe := 0; ; probe++ {
+		id := stableClusterID(zoom, hash, probe)
+		if _, ok := c.clusters[id]; !ok {
+			return id
+		}
+	}
+}
+
+// initLeaves sets hashes of the original points from their IDs, so stable cluster IDs don't depend on their order,
+// weights and groups of the original points.
+func (c *Cluster) initLeaves(leaves []*Point) {
+	for _, p := range leaves {
+		p.Weight = c.leafWeight(int(p.ID))
+
+		if c.stableIDs {
+			p.hash = leafHash(c.leafID(int(p.ID)))
+		}
+
+		if c.leafGroup != nil {
+			p.Group = c.leafGroup(int(p.ID))
+		}
+	}
+}
+
+// hashClusters restores hashes of all points and clusters, from the original points to the top zoom level.
+func (c *Cluster) hashClusters() {
+	for _, kp := range c.Indexes[len(c.Indexes)-1].Points {
+		p := kp.(*Point)
+		p.hash = leafHash(c.leafID(int(p.ID)))
+	}
+
+	for level := len(c.clusterLevels) - 1; level >= 0; level-- {
+		for slot, p := range c.clusterLevels[level].points {
+			p.hash = 0
+
+			for _, child := range c.clusterLevels[level].childrenOf(slot) {
+				p.hash += child.hash
+			}
+		}
+	}
+}
+
+// Radius returns the clustering radius in pixels at the zoom level.
+// It's PointSize, unless WithRadiusFunc option is provided.
+func (c *Cluster) Radius(zoom int) float64 {
+	if c.radiusFn != nil {
+		return c.radiusFn(zoom)
+	}
+
+	return float64(c.PointSize)
+}
+
+// worldRadius returns the clustering radius at the zoom level in world coordinates of the projection.
+func (c *Cluster) worldRadius(zoom int) float64 {
+	return c.Radius(zoom) / float64(c.TileSize*(1<<uint(zoom)))
 }
 
 func (c *Cluster) LimitZoom(zoom int) int {
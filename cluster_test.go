@@ -1,8 +1,10 @@
 package cluster_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -174,6 +176,7 @@ func TestCluster_GetClusters_Included(t *testing.T) {
 		name     string
 		input    []*cluster.Point
 		expected []*cluster.Point
+		included [][]int64
 	}{
 		{
 			name: "two points same location, one cluster",
@@ -182,8 +185,9 @@ func TestCluster_GetClusters_Included(t *testing.T) {
 				{ID: 1, NumPoints: 1, X: 20.8, Y: 52.2},
 			},
 			expected: []*cluster.Point{
-				{ID: 0, NumPoints: 2, X: 20.8, Y: 52.2, Included: []int64{0, 1}},
+				{ID: 0, NumPoints: 2, X: 20.8, Y: 52.2},
 			},
+			included: [][]int64{{0, 1}},
 		},
 		{
 			name: "two points different location, one cluster",
@@ -192,8 +196,9 @@ func TestCluster_GetClusters_Included(t *testing.T) {
 				{ID: 1, NumPoints: 1, X: 20.83, Y: 52.23},
 			},
 			expected: []*cluster.Point{
-				{ID: 0, NumPoints: 2, X: 20.82, Y: 52.2200011258, Included: []int64{0, 1}},
+				{ID: 0, NumPoints: 2, X: 20.82, Y: 52.2200011258},
 			},
+			included: [][]int64{{0, 1}},
 		},
 		{
 			name: "three points different location, one cluster",
@@ -203,8 +208,9 @@ func TestCluster_GetClusters_Included(t *testing.T) {
 				{ID: 2, NumPoints: 1, X: 20.85, Y: 52.25},
 			},
 			expected: []*cluster.Point{
-				{ID: 0, NumPoints: 3, X: 20.83, Y: 52.23000300333, Included: []int64{0, 1, 2}},
+				{ID: 0, NumPoints: 3, X: 20.83, Y: 52.23000300333},
 			},
+			included: [][]int64{{0, 1, 2}},
 		},
 		{
 			name: "three points different location, two clusters",
@@ -214,9 +220,10 @@ func TestCluster_GetClusters_Included(t *testing.T) {
 				{ID: 2, NumPoints: 1, X: 22.00, Y: 54.00},
 			},
 			expected: []*cluster.Point{
-				{ID: 0, NumPoints: 2, X: 20.82, Y: 52.2200011258, Included: []int64{0, 1}},
-				{ID: 1, NumPoints: 1, X: 22.00, Y: 54.00, Included: []int64{2}},
+				{ID: 0, NumPoints: 2, X: 20.82, Y: 52.2200011258},
+				{ID: 1, NumPoints: 1, X: 22.00, Y: 54.00},
 			},
+			included: [][]int64{{0, 1}, {2}},
 		},
 	}
 
@@ -244,7 +251,7 @@ func TestCluster_GetClusters_Included(t *testing.T) {
 				assert.Truef(t, floatEquals(ep.X, rp.X), "X coordinates don't match: %2.11f and %2.11f", ep.X, rp.X)
 				assert.Truef(t, floatEquals(ep.Y, rp.Y), "Y coordinates don't match: %2.11f and %2.11f", ep.Y, rp.Y)
 				assert.Equalf(t, ep.NumPoints, rp.NumPoints, "points count doesn't match")
-				assert.Equalf(t, ep.Included, rp.Included, "included points don't match")
+				assert.Equalf(t, tt.included[i], rp.Included(c), "included points don't match")
 			}
 		})
 	}
@@ -271,6 +278,337 @@ func TestCluster_AllClusters(t *testing.T) {
 	assert.Equal(t, 100, len(result))
 }
 
+func TestCluster_WithAggregator(t *testing.T) {
+	points := importData("./testdata/places.json")
+	assert.NotEmptyf(t, points, "no points for clustering")
+
+	geoPoints := make([]cluster.GeoPoint, len(points))
+
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	type stats struct {
+		Sum, Max int
+	}
+
+	c, err := cluster.New(geoPoints,
+		cluster.WithinZoom(0, 17),
+		cluster.WithAggregator(
+			func(p cluster.GeoPoint) interface{} {
+				rank := p.(*TestPoint).Properties.ScaleRank
+
+				return stats{Sum: rank, Max: rank}
+			},
+			func(accumulated, props interface{}) interface{} {
+				a, b := accumulated.(stats), props.(stats)
+				if b.Max > a.Max {
+					a.Max = b.Max
+				}
+				a.Sum += b.Sum
+
+				return a
+			}))
+	require.NoError(t, err)
+
+	var expectedSum, expectedMax, expectedNumPoints int
+
+	for i := range points {
+		// points without coordinates are skipped
+		if points[i].GetCoordinates() == nil {
+			continue
+		}
+
+		expectedNumPoints++
+		expectedSum += points[i].Properties.ScaleRank
+		if points[i].Properties.ScaleRank > expectedMax {
+			expectedMax = points[i].Properties.ScaleRank
+		}
+	}
+
+	var sum, max, numPoints int
+
+	for _, p := range c.AllClusters(0, -1) {
+		s := p.Properties.(stats)
+		sum += s.Sum
+		numPoints += p.NumPoints
+
+		if s.Max > max {
+			max = s.Max
+		}
+	}
+
+	assert.Equal(t, expectedNumPoints, numPoints)
+	assert.Equal(t, expectedSum, sum)
+	assert.Equal(t, expectedMax, max)
+
+	_, err = cluster.New(geoPoints, cluster.WithAggregator(nil, nil))
+	assert.Equal(t, cluster.ErrInvalidAggregator, err)
+}
+
+func TestCluster_GetLeaves(t *testing.T) {
+	points := importData("./testdata/places.json")
+	assert.NotEmptyf(t, points, "no points for clustering")
+
+	geoPoints := make([]cluster.GeoPoint, len(points))
+
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	seen := make(map[cluster.GeoPoint]bool)
+
+	for _, p := range c.AllClusters(0, -1) {
+		if !p.IsCluster(c) {
+			seen[c.Points[p.ID]] = true
+
+			continue
+		}
+
+		leaves, err := c.GetLeaves(p.ID, -1, 0)
+		require.NoError(t, err)
+		require.Equal(t, p.NumPoints, len(leaves))
+
+		for _, leaf := range leaves {
+			assert.Falsef(t, seen[leaf], "leaf returned twice")
+			seen[leaf] = true
+		}
+
+		page, err := c.GetLeaves(p.ID, 10, 5)
+		require.NoError(t, err)
+
+		end := 15
+		if end > len(leaves) {
+			end = len(leaves)
+		}
+
+		if len(leaves) > 5 {
+			assert.Equal(t, leaves[5:end], page)
+		} else {
+			assert.Empty(t, page)
+		}
+	}
+
+	assert.Equal(t, len(points)-1, len(seen)) // one point has no coordinates
+
+	_, err = c.GetLeaves(5, -1, 0)
+	assert.Equal(t, cluster.ErrClusterNotFound, err)
+
+	_, err = c.GetLeaves(1<<30, -1, 0)
+	assert.Equal(t, cluster.ErrClusterNotFound, err)
+}
+
+func TestCluster_GetChildren(t *testing.T) {
+	points := importData("./testdata/places.json")
+	assert.NotEmptyf(t, points, "no points for clustering")
+
+	geoPoints := make([]cluster.GeoPoint, len(points))
+
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	for _, zoom := range [][2]int{{0, 17}, {2, 10}, {5, 5}} {
+		c, err := cluster.New(geoPoints, cluster.WithinZoom(zoom[0], zoom[1]))
+		require.NoError(t, err)
+
+		for z := zoom[0]; z <= zoom[1]; z++ {
+			for _, p := range c.AllClusters(z, -1) {
+				children, err := c.GetChildren(p.ID)
+				if !p.IsCluster(c) {
+					assert.Equal(t, cluster.ErrClusterNotFound, err)
+
+					continue
+				}
+
+				require.NoError(t, err)
+				assert.Truef(t, len(children) > 1, "cluster must have at least two children")
+
+				numPoints := 0
+				for _, child := range children {
+					numPoints += child.NumPoints
+				}
+
+				assert.Equal(t, p.NumPoints, numPoints)
+				assert.Truef(t, c.GetClusterExpansionZoom(p.ID) > z || z == zoom[1], "cluster must expand at greater zoom")
+			}
+		}
+	}
+
+	c, err := cluster.New(geoPoints)
+	require.NoError(t, err)
+
+	_, err = c.GetChildren(-1)
+	assert.Equal(t, cluster.ErrClusterNotFound, err)
+
+	_, err = c.GetChildren(1<<30 + 3)
+	assert.Equal(t, cluster.ErrClusterNotFound, err)
+}
+
+func TestDecodeClusterID(t *testing.T) {
+	points := importSimplePoints("./testdata/places.json")
+
+	for _, zoom := range [][2]int{{0, 17}, {3, 10}} {
+		c, err := cluster.New(points, cluster.WithinZoom(zoom[0], zoom[1]))
+		require.NoError(t, err)
+
+		seen := make(map[int64]bool)
+
+		for z := zoom[0]; z <= zoom[1]+1; z++ {
+			for _, p := range c.AllClusters(z, -1) {
+				clusterZoom, index, isCluster := cluster.DecodeClusterID(p.ID)
+				assert.Equal(t, p.IsCluster(c), isCluster)
+				assert.Less(t, p.ID, int64(1)<<53, "ID must be exactly represented by JavaScript numbers")
+
+				if !isCluster {
+					assert.Equal(t, cluster.InfinityZoomLevel, clusterZoom)
+					assert.Equal(t, p.ID, int64(index))
+
+					continue
+				}
+				// clusters are passed to the zoom levels above unchanged
+				assert.GreaterOrEqual(t, clusterZoom, z)
+				assert.LessOrEqual(t, clusterZoom, zoom[1])
+
+				if clusterZoom == z {
+					assert.Falsef(t, seen[p.ID], "cluster ID %d is not unique", p.ID)
+					seen[p.ID] = true
+				}
+			}
+		}
+	}
+
+	zoom, index, isCluster := cluster.DecodeClusterID(42)
+	assert.Equal(t, cluster.InfinityZoomLevel, zoom)
+	assert.Equal(t, 42, index)
+	assert.False(t, isCluster)
+}
+
+func TestCluster_WithRadiusFunc(t *testing.T) {
+	points := importSimplePoints("./testdata/places.json")
+
+	_, err := cluster.New(points, cluster.WithRadiusFunc(nil))
+	assert.Equal(t, cluster.ErrInvalidRadiusFunc, err)
+
+	// constant radius function is the same as the point size
+	c, err := cluster.New(points, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	constant, err := cluster.New(points, cluster.WithinZoom(0, 16), cluster.WithRadiusFunc(func(zoom int) float64 {
+		return 40
+	}))
+	require.NoError(t, err)
+	assertSameIndexes(t, c, constant)
+
+	radius := func(zoom int) float64 {
+		if zoom <= 3 {
+			return 120
+		}
+
+		return 10
+	}
+
+	varying, err := cluster.New(points, cluster.WithinZoom(0, 16), cluster.WithRadiusFunc(radius))
+	require.NoError(t, err)
+	assertHierarchy(t, varying, len(points))
+
+	assert.Equal(t, 120.0, varying.Radius(2))
+	assert.Equal(t, 10.0, varying.Radius(8))
+	assert.Equal(t, 40.0, c.Radius(8))
+	assert.Less(t, len(varying.AllClusters(3, -1)), len(c.AllClusters(3, -1)))
+	assert.Greater(t, len(varying.AllClusters(4, -1)), len(c.AllClusters(4, -1)))
+
+	// tile buffer is the clustering radius
+	for z, buffer := range map[int]float64{2: 120, 6: 10} {
+		outside := false
+
+		for _, tile := range varying.NonEmptyTiles(z) {
+			// tiles along the antimeridian have wrapped points of the opposite side
+			if tile.X == 0 || tile.X == 1<<uint(z)-1 {
+				continue
+			}
+
+			for _, p := range varying.GetTile(tile.X, tile.Y, tile.Z) {
+				for _, v := range []float64{p.X, p.Y} {
+					assert.GreaterOrEqual(t, v, -buffer)
+					assert.LessOrEqual(t, v, 512+buffer)
+					outside = outside || v < -40 || v > 512+40
+				}
+			}
+		}
+
+		assert.Equal(t, buffer > 40, outside, "zoom %d", z)
+	}
+
+	// all children are found with the radius of the cluster zoom
+	for _, p := range varying.AllClusters(2, -1) {
+		if p.IsCluster(varying) {
+			expansionZoom := varying.GetClusterExpansionZoom(p.ID)
+			assert.GreaterOrEqual(t, expansionZoom, 3)
+
+			leaves, err := varying.GetLeaves(p.ID, -1, 0)
+			require.NoError(t, err)
+			assert.Len(t, leaves, p.NumPoints)
+		}
+	}
+}
+
+func TestCluster_WithMinPoints(t *testing.T) {
+	points := []cluster.GeoPoint{
+		simplePoint{0, 10, 10},
+		simplePoint{1, 10.01, 10.01},
+		simplePoint{2, -10, -10},
+		simplePoint{3, -10.01, -10},
+		simplePoint{4, -10, -10.01},
+		simplePoint{5, -10.01, -10.01},
+	}
+
+	c, err := cluster.New(points, cluster.WithinZoom(0, 5), cluster.WithMinPoints(3))
+	require.NoError(t, err)
+	assert.Equal(t, 3, c.MinPoints)
+	assertHierarchy(t, c, len(points))
+
+	for z := 0; z <= 5; z++ {
+		clusters := c.AllClusters(z, -1)
+		require.Len(t, clusters, 3, "zoom %d", z)
+
+		for _, p := range clusters {
+			if p.IsCluster(c) {
+				assert.Equal(t, 4, p.NumPoints)
+			} else {
+				assert.Contains(t, []int64{0, 1}, p.ID)
+			}
+		}
+	}
+
+	// larger data set
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err = cluster.New(geoPoints, cluster.WithinZoom(0, 16), cluster.WithMinPoints(5))
+	require.NoError(t, err)
+	assertHierarchy(t, c, len(geoPoints))
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		for _, p := range c.AllClusters(z, -1) {
+			if p.IsCluster(c) {
+				assert.GreaterOrEqual(t, p.NumPoints, 5)
+			}
+		}
+	}
+
+	// default value merges any neighbours
+	defaults, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	pairs, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16), cluster.WithMinPoints(2))
+	require.NoError(t, err)
+	assertSameIndexes(t, defaults, pairs)
+	assert.Greater(t, len(c.AllClusters(3, -1)), len(defaults.AllClusters(3, -1)))
+}
+
 func ExampleCluster_GetClusters() {
 	points := importData("./testdata/places.json")
 
@@ -285,9 +623,465 @@ func ExampleCluster_GetClusters() {
 	southEast := simplePoint{-1, 71.36718750000001, -83.79204408779539}
 	result, err := c.GetClusters(northWest, southEast, 2, -1)
 	if err != nil {
-		fmt.Errorf("unable to get clusters: %v", err)
+		fmt.Printf("unable to get clusters: %v", err)
+
+		return
+	}
+
+	for _, p := range result[:3] {
+		fmt.Printf("X:%v Y:%v ID:%d NumPoints:%d Weight:%v\n", p.X, p.Y, p.ID, p.NumPoints, p.Weight)
 	}
+	// Output:
+	// X:-14.473194953510028 Y:26.157965399212813 ID:107 NumPoints:1 Weight:1
+	// X:-12.408741828510014 Y:58.16339752811905 ID:159 NumPoints:1 Weight:1
+	// X:-9.269962828651519 Y:42.928736057812586 ID:127 NumPoints:1 Weight:1
+}
+
+// clusterIDsByMembers maps zoom level and sorted IDs of the original points of each cluster to the cluster ID.
+func clusterIDsByMembers(c *cluster.Cluster) map[string]int64 {
+	ids := make(map[string]int64)
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		for _, p := range c.AllClusters(z, -1) {
+			if p.IsCluster(c) {
+				included := p.Included(c)
+				sortIDs(included)
+				ids[fmt.Sprint(z, included)] = p.ID
+			}
+		}
+	}
+
+	return ids
+}
+
+func TestCluster_WithStableIDs(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+	// positions of the following points are shifted and some clusters are not created anymore,
+	// but IDs of the points remain the same
+	refreshed := append(append([]cluster.GeoPoint(nil), geoPoints[:2]...), geoPoints[3:]...)
+
+	rebuildIDs := func(opts ...cluster.Option) (before, after map[string]int64) {
+		c, err := cluster.New(geoPoints, append(opts, cluster.WithinZoom(0, 16))...)
+		require.NoError(t, err)
+		assertHierarchy(t, c, len(geoPoints))
+
+		rebuilt, err := cluster.New(refreshed, append(opts, cluster.WithinZoom(0, 16))...)
+		require.NoError(t, err)
+		assertHierarchy(t, rebuilt, len(refreshed))
 
-	fmt.Printf("%+v", result[:3])
-	// Output: [{X:-14.473194953510028 Y:26.157965399212813 zoom:1 ID:107 NumPoints:1 Included:[0]} {X:-12.408741828510014 Y:58.16339752811905 zoom:1 ID:159 NumPoints:1 Included:[0]} {X:-9.269962828651519 Y:42.928736057812586 zoom:1 ID:127 NumPoints:1 Included:[0]}]
+		return clusterIDsByMembers(c), clusterIDsByMembers(rebuilt)
+	}
+	// count clusters with the same members, which keep or change their IDs after rebuild
+	compare := func(before, after map[string]int64) (same, changed int) {
+		for members, id := range after {
+			if beforeID, ok := before[members]; ok {
+				if beforeID == id {
+					same++
+				} else {
+					changed++
+				}
+			}
+		}
+
+		return same, changed
+	}
+
+	same, changed := compare(rebuildIDs())
+	assert.Greater(t, changed, 0, "sequential IDs depend on the clustering order")
+
+	before, after := rebuildIDs(cluster.WithStableIDs())
+	same, changed = compare(before, after)
+	assert.Greater(t, same, len(after)/2)
+	assert.Equal(t, 0, changed)
+
+	// incremental updates assign the same IDs to clusters with the same members
+	c, err := cluster.New(geoPoints[:150], cluster.WithinZoom(0, 16), cluster.WithStableIDs())
+	require.NoError(t, err)
+
+	c.Insert(geoPoints[150:]...)
+	c.Remove(2)
+	assertHierarchy(t, c, len(refreshed))
+
+	same, changed = compare(after, clusterIDsByMembers(c))
+	assert.Greater(t, same, 0)
+	assert.Equal(t, 0, changed)
+
+	// parallel build assigns the same IDs
+	parallel, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16), cluster.WithStableIDs(), cluster.WithParallelism(4))
+	require.NoError(t, err)
+	assert.Equal(t, before, clusterIDsByMembers(parallel))
+}
+
+func TestCluster_WithGroupBy(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+	group := func(p cluster.GeoPoint) string {
+		if p.GetID()%3 == 0 {
+			return "hospital"
+		}
+
+		return "fuel"
+	}
+	groups := map[string][]cluster.GeoPoint{}
+
+	for _, p := range geoPoints {
+		groups[group(p)] = append(groups[group(p)], p)
+	}
+
+	opts := []cluster.Option{cluster.WithinZoom(0, 16), cluster.WithStableIDs()}
+
+	c, err := cluster.New(geoPoints, append(opts, cluster.WithGroupBy(group))...)
+	require.NoError(t, err)
+	assertHierarchy(t, c, len(geoPoints))
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		for _, p := range c.AllClusters(z, -1) {
+			for _, id := range p.Included(c) {
+				require.Equal(t, group(geoPoints[id]), p.Group)
+			}
+		}
+	}
+	// each group is clustered the same way as separately clustered points of the group
+	world := []cluster.GeoPoint{&cluster.Point{X: -180, Y: 90}, &cluster.Point{X: 180, Y: -90}}
+
+	for name, points := range groups {
+		separate, err := cluster.New(points, opts...)
+		require.NoError(t, err)
+
+		for z := 0; z <= 17; z += 4 {
+			actual, err := c.GetClusters(world[0], world[1], z, -1, name)
+			require.NoError(t, err)
+
+			for i := range actual {
+				assert.Equal(t, name, actual[i].Group)
+				actual[i].Group = ""
+			}
+
+			assert.Equal(t, describePoints(separate.AllClusters(z, -1), points), describePoints(actual, geoPoints))
+		}
+
+		for _, p := range c.GetTile(0, 0, 3, name) {
+			assert.Equal(t, name, p.Group)
+		}
+	}
+
+	all, err := c.GetClusters(world[0], world[1], 5, -1)
+	require.NoError(t, err)
+
+	both, err := c.GetClusters(world[0], world[1], 5, -1, "fuel", "hospital")
+	require.NoError(t, err)
+	assert.Equal(t, all, both)
+
+	none, err := c.GetClusters(world[0], world[1], 5, -1, "school")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	collection := c.ToFeatureCollection(all, cluster.GeoJSONOptions{})
+	for _, feature := range collection.Features {
+		assert.Contains(t, []interface{}{"fuel", "hospital"}, feature.Properties["group"])
+	}
+
+	parallel, err := cluster.New(geoPoints, append(opts, cluster.WithGroupBy(group), cluster.WithParallelism(4))...)
+	require.NoError(t, err)
+	assertSameIndexes(t, c, parallel)
+
+	// inserted points are grouped as well
+	c, err = cluster.New(geoPoints[:100], append(opts, cluster.WithGroupBy(group))...)
+	require.NoError(t, err)
+
+	c.Insert(geoPoints[100:]...)
+	c.Remove(3, 4)
+	assertHierarchy(t, c, len(geoPoints)-2)
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		for _, p := range c.AllClusters(z, -1) {
+			for _, id := range p.Included(c) {
+				require.Equal(t, group(geoPoints[id]), p.Group)
+			}
+		}
+	}
+
+	_, err = cluster.New(geoPoints, cluster.WithGroupBy(nil))
+	assert.Equal(t, cluster.ErrInvalidGroupFunc, err)
+}
+
+func TestCluster_WeightedPoints(t *testing.T) {
+	points := []cluster.GeoPoint{
+		weightedPoint{simplePoint{0, 0, 0}, 3},
+		weightedPoint{simplePoint{1, 4, 0}, 1},
+		weightedPoint{simplePoint{2, 80, 0}, 0},
+		weightedPoint{simplePoint{3, 84, 0}, -2},
+		simplePoint{4, -80, 0},
+		weightedPoint{simplePoint{5, -84, 0}, 1},
+	}
+
+	c, err := cluster.New(points, cluster.WithinZoom(0, 0))
+	require.NoError(t, err)
+
+	clusters := c.AllClusters(0, -1)
+	require.Len(t, clusters, 3)
+	// weighted center
+	assert.InDelta(t, 1, clusters[0].X, 1e-9)
+	assert.Equal(t, 4.0, clusters[0].Weight)
+	// center of points without weight
+	assert.InDelta(t, 82, clusters[1].X, 1e-9)
+	assert.Equal(t, 0.0, clusters[1].Weight)
+	// points, not implementing WeightedGeoPoint, have weight 1
+	assert.InDelta(t, -82, clusters[2].X, 1e-9)
+	assert.Equal(t, 2.0, clusters[2].Weight)
+}
+
+func TestCluster_WithWeightedSeeds(t *testing.T) {
+	// the middle point is within the radius of both others, which are too far from each other
+	points := []cluster.GeoPoint{
+		weightedPoint{simplePoint{0, 0, 0}, 1},
+		weightedPoint{simplePoint{1, 5, 0}, 5},
+		weightedPoint{simplePoint{2, 10, 0}, 1},
+	}
+
+	c, err := cluster.New(points, cluster.WithinZoom(2, 2))
+	require.NoError(t, err)
+	assert.Len(t, c.AllClusters(2, -1), 2)
+
+	c, err = cluster.New(points, cluster.WithinZoom(2, 2), cluster.WithWeightedSeeds())
+	require.NoError(t, err)
+
+	clusters := c.AllClusters(2, -1)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, 3, clusters[0].NumPoints)
+	assert.InDelta(t, 5, clusters[0].X, 1e-9)
+
+	// larger data set
+	geoPoints := importSimplePoints("./testdata/places.json")
+	weighted := make([]cluster.GeoPoint, len(geoPoints))
+	weights := make(map[int64]float64)
+
+	for i, p := range geoPoints {
+		weights[p.GetID()] = float64(i % 7)
+		weighted[i] = weightedPoint{p.(simplePoint), weights[p.GetID()]}
+	}
+
+	opts := []cluster.Option{cluster.WithinZoom(0, 16), cluster.WithWeightedSeeds()}
+
+	c, err = cluster.New(weighted, opts...)
+	require.NoError(t, err)
+	assertHierarchy(t, c, len(weighted))
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		for _, p := range c.AllClusters(z, -1) {
+			total := 0.0
+			for _, id := range p.Included(c) {
+				total += weights[id]
+			}
+
+			require.Equal(t, total, p.Weight)
+		}
+	}
+
+	parallel, err := cluster.New(weighted, append(opts, cluster.WithParallelism(4))...)
+	require.NoError(t, err)
+	assertSameIndexes(t, c, parallel)
+
+	c, err = cluster.New(weighted[:100], opts...)
+	require.NoError(t, err)
+
+	c.Insert(weighted[100:]...)
+	c.Remove(3, 4)
+	assertHierarchy(t, c, len(weighted)-2)
+
+	var buf bytes.Buffer
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	// updates of the cluster modify its points, so the loaded cluster gets a copy of them
+	loaded, err := cluster.Load(&buf, append([]cluster.GeoPoint(nil), c.Points...))
+	require.NoError(t, err)
+	assertSameIndexes(t, c, loaded)
+
+	c.Remove(5, 6)
+	loaded.Remove(5, 6)
+	assertSameIndexes(t, c, loaded)
+}
+
+// assertBounds verifies that bounds of all clusters are the extents of their original points.
+func assertBounds(t *testing.T, c *cluster.Cluster) {
+	t.Helper()
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		for _, p := range c.AllClusters(z, -1) {
+			if !p.IsCluster(c) {
+				_, err := c.GetClusterBounds(p.ID)
+				require.Equal(t, cluster.ErrClusterNotFound, err)
+
+				continue
+			}
+
+			bounds, err := c.GetClusterBounds(p.ID)
+			require.NoError(t, err)
+
+			leaves, err := c.GetLeaves(p.ID, -1, 0)
+			require.NoError(t, err)
+
+			expected := cluster.Bounds{MinLng: 180, MinLat: 90, MaxLng: -180, MaxLat: -90}
+			for _, leaf := range leaves {
+				coordinates := leaf.GetCoordinates()
+				expected.MinLng = math.Min(expected.MinLng, coordinates.Lng)
+				expected.MinLat = math.Min(expected.MinLat, coordinates.Lat)
+				expected.MaxLng = math.Max(expected.MaxLng, coordinates.Lng)
+				expected.MaxLat = math.Max(expected.MaxLat, coordinates.Lat)
+			}
+
+			require.InDelta(t, expected.MinLng, bounds.MinLng, 1e-9)
+			require.InDelta(t, expected.MinLat, bounds.MinLat, 1e-9)
+			require.InDelta(t, expected.MaxLng, bounds.MaxLng, 1e-9)
+			require.InDelta(t, expected.MaxLat, bounds.MaxLat, 1e-9)
+			require.True(t, p.X >= bounds.MinLng-1e-9 && p.X <= bounds.MaxLng+1e-9, "cluster %d", p.ID)
+			require.True(t, p.Y >= bounds.MinLat-1e-9 && p.Y <= bounds.MaxLat+1e-9, "cluster %d", p.ID)
+		}
+	}
+}
+
+func TestCluster_GetClusterBounds(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+	assertBounds(t, c)
+
+	parallel, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16), cluster.WithParallelism(4))
+	require.NoError(t, err)
+	assertBounds(t, parallel)
+
+	var buf bytes.Buffer
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err := cluster.Load(&buf, geoPoints)
+	require.NoError(t, err)
+	assertBounds(t, loaded)
+
+	c, err = cluster.New(geoPoints[:100], cluster.WithinZoom(2, 14), cluster.WithStableIDs())
+	require.NoError(t, err)
+
+	c.Insert(geoPoints[100:]...)
+	c.Remove(3, 4)
+	assertBounds(t, c)
+
+	_, err = c.GetClusterBounds(-1)
+	assert.Equal(t, cluster.ErrClusterNotFound, err)
+}
+
+func TestCluster_GetBounds(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	expected := cluster.Bounds{MinLng: 180, MinLat: 90, MaxLng: -180, MaxLat: -90}
+	for _, p := range geoPoints {
+		coordinates := p.GetCoordinates()
+		expected.MinLng = math.Min(expected.MinLng, coordinates.Lng)
+		expected.MinLat = math.Min(expected.MinLat, coordinates.Lat)
+		expected.MaxLng = math.Max(expected.MaxLng, coordinates.Lng)
+		expected.MaxLat = math.Max(expected.MaxLat, coordinates.Lat)
+	}
+
+	bounds := c.GetBounds()
+	assert.InDelta(t, expected.MinLng, bounds.MinLng, 1e-9)
+	assert.InDelta(t, expected.MinLat, bounds.MinLat, 1e-9)
+	assert.InDelta(t, expected.MaxLng, bounds.MaxLng, 1e-9)
+	assert.InDelta(t, expected.MaxLat, bounds.MaxLat, 1e-9)
+
+	// the whole world of the projection without points
+	empty, err := cluster.New(nil, cluster.WithProjection(cluster.Equirectangular{}))
+	require.NoError(t, err)
+	assert.Equal(t, cluster.Bounds{MinLng: -180, MinLat: -270, MaxLng: 180, MaxLat: 90}, empty.GetBounds())
+}
+
+func TestCluster_WithCentroidStrategy(t *testing.T) {
+	_, err := cluster.New(nil, cluster.WithCentroidStrategy(cluster.CentroidStrategy(42)))
+	assert.Equal(t, cluster.ErrInvalidCentroidStrategy, err)
+
+	geoPoints := importSimplePoints("./testdata/places.json")
+	prioritized := make([]cluster.GeoPoint, len(geoPoints))
+	priorities := make(map[int64]float64)
+
+	for i, p := range geoPoints {
+		priorities[p.GetID()] = float64((i * 37) % len(geoPoints))
+		prioritized[i] = prioritizedPoint{p.(simplePoint), priorities[p.GetID()]}
+	}
+
+	for _, strategy := range []cluster.CentroidStrategy{cluster.CentroidMedoid, cluster.CentroidHighestPriority} {
+		opts := []cluster.Option{cluster.WithinZoom(0, 16), cluster.WithCentroidStrategy(strategy)}
+
+		c, err := cluster.New(prioritized, opts...)
+		require.NoError(t, err)
+		assertHierarchy(t, c, len(prioritized))
+
+		mean, err := cluster.New(prioritized, cluster.WithinZoom(0, 16))
+		require.NoError(t, err)
+
+		moved := 0
+
+		for z := c.MinZoom; z <= c.MaxZoom; z++ {
+			for _, p := range c.AllClusters(z, -1) {
+				if !p.IsCluster(c) {
+					continue
+				}
+
+				leaves, err := c.GetLeaves(p.ID, -1, 0)
+				require.NoError(t, err)
+
+				var position cluster.GeoPoint
+
+				for _, leaf := range leaves {
+					coordinates := leaf.GetCoordinates()
+
+					if strategy == cluster.CentroidHighestPriority {
+						if position == nil || priorities[leaf.GetID()] > priorities[position.GetID()] {
+							position = leaf
+						}
+					} else if math.Abs(coordinates.Lng-p.X) < 1e-9 && math.Abs(coordinates.Lat-p.Y) < 1e-9 {
+						position = leaf
+					}
+				}
+
+				require.NotNil(t, position, "cluster %d isn't at its point", p.ID)
+				require.InDelta(t, position.GetCoordinates().Lng, p.X, 1e-9)
+				require.InDelta(t, position.GetCoordinates().Lat, p.Y, 1e-9)
+			}
+
+			if len(c.AllClusters(z, -1)) != len(mean.AllClusters(z, -1)) {
+				moved++
+			}
+		}
+		// clusters are clustered at their positions, so the hierarchy differs from the weighted mean one
+		assert.NotZero(t, moved)
+
+		parallel, err := cluster.New(prioritized, append(opts, cluster.WithParallelism(4))...)
+		require.NoError(t, err)
+		assertSameIndexes(t, c, parallel)
+
+		c, err = cluster.New(prioritized[:100], opts...)
+		require.NoError(t, err)
+
+		c.Insert(prioritized[100:]...)
+		c.Remove(3, 4)
+		assertHierarchy(t, c, len(prioritized)-2)
+
+		var buf bytes.Buffer
+
+		_, err = c.WriteTo(&buf)
+		require.NoError(t, err)
+
+		// updates of the cluster modify its points, so the loaded cluster gets a copy of them
+		loaded, err := cluster.Load(&buf, append([]cluster.GeoPoint(nil), c.Points...))
+		require.NoError(t, err)
+		assertSameIndexes(t, c, loaded)
+
+		c.Remove(5, 6)
+		loaded.Remove(5, 6)
+		assertSameIndexes(t, c, loaded)
+	}
 }
@@ -0,0 +1,48 @@
+// Command gocluster builds clusters of point files and exports them.
+//
+// Usage:
+//
+//	gocluster tile [flags] points.geojson
+//	gocluster tile -format pmtiles -output places.pmtiles points.geojson
+//
+// Commands:
+//
+//	tile    pre-generate a static pyramid of clustered tiles, or a PMTiles archive
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: gocluster <command> [flags] <points file>
+
+Commands:
+  tile    pre-generate a static pyramid of clustered tiles, or a PMTiles archive
+
+Run "gocluster <command> -h" for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "tile":
+		err = runTile(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
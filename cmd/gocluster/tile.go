@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/aliakseiz/gocluster/cmd/internal/pointfile"
+)
+
+var errInvalidFormat = errors.New("format must be pbf, json or pmtiles")
+
+// tileOptions are the options of tile command.
+type tileOptions struct {
+	input     string
+	output    string
+	format    string
+	name      string
+	layer     string
+	extent    int
+	minZoom   int
+	maxZoom   int
+	pointSize int
+	tileSize  int
+	nodeSize  int
+	minPoints int
+	workers   int
+}
+
+func runTile(args []string) error {
+	var opts tileOptions
+
+	fs := flag.NewFlagSet("tile", flag.ExitOnError)
+	fs.StringVar(&opts.output, "output", "", "output directory, or archive file for pmtiles format (default \"tiles\" or \"tiles.pmtiles\")")
+	fs.StringVar(&opts.format, "format", "pbf", "tiles format: pbf (Mapbox Vector Tile), json (GeoJSON) or pmtiles (PMTiles archive of vector tiles)")
+	fs.StringVar(&opts.name, "name", "", "tileset name in TileJSON, input file name by default")
+	fs.StringVar(&opts.layer, "layer", cluster.DefaultMVTLayerName, "vector tile layer name")
+	fs.IntVar(&opts.extent, "extent", cluster.DefaultMVTExtent, "vector tile extent")
+	fs.IntVar(&opts.minZoom, "min-zoom", 0, "minimum zoom level of clusters and tiles")
+	fs.IntVar(&opts.maxZoom, "max-zoom", 16, "maximum zoom level of clusters and tiles")
+	fs.IntVar(&opts.pointSize, "point-size", 40, "cluster radius in pixels")
+	fs.IntVar(&opts.tileSize, "tile-size", 512, "tile size in pixels, radius is calculated relative to it")
+	fs.IntVar(&opts.nodeSize, "node-size", 64, "KD-tree node size")
+	fs.IntVar(&opts.minPoints, "min-points", 2, "minimum number of points to form a cluster")
+	fs.IntVar(&opts.workers, "workers", runtime.NumCPU(), "number of goroutines clustering points and generating tiles")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gocluster tile [flags] <points.geojson|points.csv>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts.input = fs.Arg(0)
+
+	if opts.name == "" {
+		opts.name = filepath.Base(opts.input)
+	}
+
+	if opts.format != "pbf" && opts.format != "json" && opts.format != "pmtiles" {
+		return errInvalidFormat
+	}
+
+	c, err := buildCluster(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.format == "pmtiles" {
+		if opts.output == "" {
+			opts.output = "tiles.pmtiles"
+		}
+
+		return writePMTiles(c, opts)
+	}
+
+	if opts.output == "" {
+		opts.output = "tiles"
+	}
+
+	start := time.Now()
+
+	count, err := generateTiles(c, opts, writeTileFile(opts))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%d tiles generated in %v", count, time.Since(start))
+
+	return writeTileJSON(c, opts)
+}
+
+// buildCluster loads points and creates the cluster.
+func buildCluster(opts tileOptions) (*cluster.Cluster, error) {
+	points, err := pointfile.Load(opts.input)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load %s: %w", opts.input, err)
+	}
+
+	start := time.Now()
+
+	c, err := cluster.New(pointfile.GeoPoints(points),
+		cluster.WithinZoom(opts.minZoom, opts.maxZoom),
+		cluster.WithPointSize(opts.pointSize),
+		cluster.WithTileSize(opts.tileSize),
+		cluster.WithNodeSize(opts.nodeSize),
+		cluster.WithMinPoints(opts.minPoints),
+		cluster.WithParallelism(opts.workers))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("%d points clustered in %v", len(points), time.Since(start))
+
+	return c, nil
+}
+
+// generateTiles encodes all non-empty tiles of the zoom range in parallel and passes them to write function.
+// write may be called concurrently. Returns number of written tiles.
+func generateTiles(c *cluster.Cluster, opts tileOptions, write func(t cluster.TileCoordinates, data []byte) error) (int, error) {
+	workers := opts.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	tiles := make(chan cluster.TileCoordinates)
+	errs := make(chan error, workers)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		count int
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for t := range tiles {
+				data, err := encodeTile(c, t, opts)
+				if err == nil {
+					err = write(t, data)
+				}
+
+				if err != nil {
+					errs <- fmt.Errorf("tile %d/%d/%d: %w", t.Z, t.X, t.Y, err)
+
+					return
+				}
+
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var err error
+
+loop:
+	for z := opts.minZoom; z <= opts.maxZoom; z++ {
+		for _, t := range c.NonEmptyTiles(z) {
+			select {
+			case tiles <- t:
+			case err = <-errs:
+				break loop
+			}
+		}
+	}
+
+	close(tiles)
+	wg.Wait()
+
+	if err == nil && len(errs) > 0 {
+		err = <-errs
+	}
+
+	return count, err
+}
+
+// encodeTile returns encoded tile.
+func encodeTile(c *cluster.Cluster, t cluster.TileCoordinates, opts tileOptions) ([]byte, error) {
+	if opts.format == "json" {
+		points := c.GetTileWithLatLng(t.X, t.Y, t.Z)
+
+		return json.Marshal(c.ToFeatureCollection(points, cluster.GeoJSONOptions{LeafProperties: leafProperties}))
+	}
+
+	return c.GetTileMVT(t.X, t.Y, t.Z, mvtOptions(opts))
+}
+
+func mvtOptions(opts tileOptions) cluster.MVTOptions {
+	return cluster.MVTOptions{
+		LayerName:      opts.layer,
+		Extent:         opts.extent,
+		LeafProperties: leafProperties,
+	}
+}
+
+func leafProperties(p cluster.GeoPoint) map[string]interface{} {
+	return p.(*pointfile.Point).Properties
+}
+
+// writeTileFile returns function writing tiles into {z}/{x}/{y}.{format} files of the output directory.
+func writeTileFile(opts tileOptions) func(t cluster.TileCoordinates, data []byte) error {
+	return func(t cluster.TileCoordinates, data []byte) error {
+		dir := filepath.Join(opts.output, fmt.Sprint(t.Z), fmt.Sprint(t.X))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.%s", t.Y, opts.format)), data, 0o644)
+	}
+}
+
+// writePMTiles writes all tiles into a single PMTiles archive.
+func writePMTiles(c *cluster.Cluster, opts tileOptions) error {
+	start := time.Now()
+
+	f, err := os.Create(opts.output)
+	if err != nil {
+		return err
+	}
+
+	err = c.WritePMTiles(f, opts.minZoom, opts.maxZoom, cluster.PMTilesOptions{
+		MVT:     mvtOptions(opts),
+		Name:    opts.name,
+		Workers: opts.workers,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%s written in %v", opts.output, time.Since(start))
+
+	return nil
+}
+
+// tileJSON returns TileJSON 3.0.0 manifest of the tileset, bounded by the cluster points.
+func tileJSON(c *cluster.Cluster, opts tileOptions, tiles string) map[string]interface{} {
+	bounds := c.GetBounds()
+	manifest := map[string]interface{}{
+		"tilejson": "3.0.0",
+		"name":     opts.name,
+		"tiles":    []string{tiles},
+		"minzoom":  opts.minZoom,
+		"maxzoom":  opts.maxZoom,
+		"bounds":   []float64{bounds.MinLng, bounds.MinLat, bounds.MaxLng, bounds.MaxLat},
+		"scheme":   "xyz",
+	}
+
+	if opts.format == "pbf" {
+		manifest["vector_layers"] = []map[string]interface{}{{
+			"id":      opts.layer,
+			"minzoom": opts.minZoom,
+			"maxzoom": opts.maxZoom,
+			"fields": map[string]string{
+				"cluster":     "Boolean",
+				"cluster_id":  "Number",
+				"point_count": "Number",
+			},
+		}}
+	}
+
+	return manifest
+}
+
+// writeTileJSON writes tilejson.json manifest into the output directory, tiles URL is relative to the manifest.
+func writeTileJSON(c *cluster.Cluster, opts tileOptions) error {
+	data, err := json.MarshalIndent(tileJSON(c, opts, "{z}/{x}/{y}."+opts.format), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(opts.output, 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(opts.output, "tilejson.json"), data, 0o644)
+}
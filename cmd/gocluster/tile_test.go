@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTileOptions(t *testing.T, format string) tileOptions {
+	t.Helper()
+
+	return tileOptions{
+		input:     "../../testdata/places.json",
+		output:    t.TempDir(),
+		format:    format,
+		name:      "places",
+		layer:     cluster.DefaultMVTLayerName,
+		extent:    cluster.DefaultMVTExtent,
+		minZoom:   0,
+		maxZoom:   5,
+		pointSize: 40,
+		tileSize:  512,
+		nodeSize:  64,
+		minPoints: 2,
+		workers:   4,
+	}
+}
+
+// writtenTiles returns relative paths of all tiles in the output directory.
+func writtenTiles(t *testing.T, dir string) map[string]bool {
+	t.Helper()
+
+	tiles := make(map[string]bool)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() == "tilejson.json" {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		tiles[filepath.ToSlash(rel)] = true
+
+		return err
+	})
+	require.NoError(t, err)
+
+	return tiles
+}
+
+func TestGenerateTiles_MVT(t *testing.T) {
+	opts := testTileOptions(t, "pbf")
+
+	c, err := buildCluster(opts)
+	require.NoError(t, err)
+
+	count, err := generateTiles(c, opts, writeTileFile(opts))
+	require.NoError(t, err)
+
+	tiles := writtenTiles(t, opts.output)
+	assert.Equal(t, count, len(tiles))
+
+	// every non-empty tile is written, and only them
+	for z := opts.minZoom; z <= opts.maxZoom; z++ {
+		for x := 0; x < 1<<uint(z); x++ {
+			for y := 0; y < 1<<uint(z); y++ {
+				path := filepath.ToSlash(filepath.Join(strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y)+".pbf"))
+				if len(c.GetTile(x, y, z)) == 0 {
+					assert.False(t, tiles[path], path)
+
+					continue
+				}
+
+				require.True(t, tiles[path], path)
+
+				expected, err := c.GetTileMVT(x, y, z, cluster.MVTOptions{LeafProperties: leafProperties})
+				require.NoError(t, err)
+
+				data, err := os.ReadFile(filepath.Join(opts.output, path))
+				require.NoError(t, err)
+				assert.Equal(t, expected, data, path)
+			}
+		}
+	}
+}
+
+func TestGenerateTiles_GeoJSON(t *testing.T) {
+	opts := testTileOptions(t, "json")
+	opts.maxZoom = 2
+
+	c, err := buildCluster(opts)
+	require.NoError(t, err)
+
+	_, err = generateTiles(c, opts, writeTileFile(opts))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(opts.output, "1", "0", "0.json"))
+	require.NoError(t, err)
+
+	var collection cluster.FeatureCollection
+	require.NoError(t, json.Unmarshal(data, &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Len(t, collection.Features, len(c.GetTile(0, 0, 1)))
+
+	for path := range writtenTiles(t, opts.output) {
+		assert.True(t, strings.HasSuffix(path, ".json"), path)
+	}
+}
+
+func TestWriteTileJSON(t *testing.T) {
+	opts := testTileOptions(t, "pbf")
+
+	c, err := buildCluster(opts)
+	require.NoError(t, err)
+	require.NoError(t, writeTileJSON(c, opts))
+
+	data, err := os.ReadFile(filepath.Join(opts.output, "tilejson.json"))
+	require.NoError(t, err)
+
+	var manifest struct {
+		TileJSON     string    `json:"tilejson"`
+		Name         string    `json:"name"`
+		Tiles        []string  `json:"tiles"`
+		MinZoom      int       `json:"minzoom"`
+		MaxZoom      int       `json:"maxzoom"`
+		Bounds       []float64 `json:"bounds"`
+		VectorLayers []struct {
+			ID string `json:"id"`
+		} `json:"vector_layers"`
+	}
+	require.NoError(t, json.Unmarshal(data, &manifest))
+
+	assert.Equal(t, "3.0.0", manifest.TileJSON)
+	assert.Equal(t, "places", manifest.Name)
+	assert.Equal(t, []string{"{z}/{x}/{y}.pbf"}, manifest.Tiles)
+	assert.Equal(t, 0, manifest.MinZoom)
+	assert.Equal(t, 5, manifest.MaxZoom)
+
+	bounds := c.GetBounds()
+	assert.Equal(t, []float64{bounds.MinLng, bounds.MinLat, bounds.MaxLng, bounds.MaxLat}, manifest.Bounds)
+	require.Len(t, manifest.VectorLayers, 1)
+	assert.Equal(t, cluster.DefaultMVTLayerName, manifest.VectorLayers[0].ID)
+}
+
+func TestWritePMTiles(t *testing.T) {
+	opts := testTileOptions(t, "pmtiles")
+	opts.output = filepath.Join(opts.output, "places.pmtiles")
+
+	c, err := buildCluster(opts)
+	require.NoError(t, err)
+	require.NoError(t, writePMTiles(c, opts))
+
+	data, err := os.ReadFile(opts.output)
+	require.NoError(t, err)
+	require.Greater(t, len(data), 127)
+	assert.Equal(t, "PMTiles", string(data[:7]))
+	assert.Equal(t, []byte{0, 5}, data[100:102], "zoom range")
+}
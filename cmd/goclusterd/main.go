@@ -0,0 +1,104 @@
+// Command goclusterd serves clustered tiles and cluster queries for point datasets.
+//
+// Each dataset is loaded from GeoJSON or CSV file at startup, the file name without extension becomes the dataset name,
+// so names of the files must differ:
+//
+//	goclusterd -addr :8080 -max-zoom 16 places.geojson shops.csv
+//
+// Endpoints:
+//
+//	GET /{dataset}/{z}/{x}/{y}.mvt                                    Mapbox Vector Tile
+//	GET /{dataset}/{z}/{x}/{y}.geojson                                GeoJSON tile
+//	GET /{dataset}/clusters?bbox={west},{south},{east},{north}&zoom={z} clusters in the bounding box
+//	GET /{dataset}/clusters/{id}/children                             children of the cluster
+//	GET /{dataset}/clusters/{id}/leaves?limit={limit}&offset={offset} original points of the cluster
+//	GET /{dataset}/clusters/{id}/expansion-zoom                       zoom to expand the cluster
+//	GET /{dataset}/clusters/{id}/bounds                               bounding box of the cluster points
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/aliakseiz/gocluster/cmd/internal/pointfile"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	minZoom := flag.Int("min-zoom", 0, "minimum zoom level to generate clusters")
+	maxZoom := flag.Int("max-zoom", 16, "maximum zoom level to generate clusters")
+	pointSize := flag.Int("point-size", 40, "cluster radius in pixels")
+	tileSize := flag.Int("tile-size", 512, "tile size in pixels, radius is calculated relative to it")
+	nodeSize := flag.Int("node-size", 64, "KD-tree node size")
+	minPoints := flag.Int("min-points", 2, "minimum number of points to form a cluster")
+	corsOrigin := flag.String("cors-origin", "*", "Access-Control-Allow-Origin header value, empty to disable CORS")
+	maxAge := flag.Int("max-age", 3600, "Cache-Control max-age of responses in seconds, 0 to disable caching")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("no point files provided")
+	}
+
+	// datasets of the files with the same base name would overwrite each other
+	paths := make(map[string]string, flag.NArg())
+
+	for _, path := range flag.Args() {
+		name := datasetName(path)
+		if other, ok := paths[name]; ok {
+			log.Fatalf("dataset %q of %s has the same name as the dataset of %s", name, path, other)
+		}
+
+		paths[name] = path
+	}
+
+	s := &server{
+		datasets:   make(map[string]*cluster.Cluster),
+		corsOrigin: *corsOrigin,
+		maxAge:     *maxAge,
+	}
+
+	for _, path := range flag.Args() {
+		name := datasetName(path)
+
+		points, err := pointfile.Load(path)
+		if err != nil {
+			log.Fatalf("unable to load %s: %v", path, err)
+		}
+
+		start := time.Now()
+
+		c, err := cluster.New(pointfile.GeoPoints(points),
+			cluster.WithinZoom(*minZoom, *maxZoom),
+			cluster.WithPointSize(*pointSize),
+			cluster.WithTileSize(*tileSize),
+			cluster.WithNodeSize(*nodeSize),
+			cluster.WithMinPoints(*minPoints))
+		if err != nil {
+			log.Fatalf("unable to cluster %s: %v", path, err)
+		}
+
+		log.Printf("dataset %q: %d points clustered in %v", name, len(points), time.Since(start))
+
+		s.datasets[name] = c
+	}
+
+	log.Printf("listening on %s", *addr)
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Fatal(srv.ListenAndServe())
+}
+
+// datasetName returns the name of the dataset of the file, which is the file name without extension.
+func datasetName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/aliakseiz/gocluster/cmd/internal/pointfile"
+)
+
+const (
+	contentTypeMVT     = "application/vnd.mapbox-vector-tile"
+	contentTypeGeoJSON = "application/geo+json"
+	contentTypeJSON    = "application/json"
+
+	// maxLeavesLimit is the maximum number of leaves, returned by one request
+	maxLeavesLimit = 1000
+)
+
+var (
+	errNotFound         = errors.New("not found")
+	errMethodNotAllowed = errors.New("method not allowed")
+)
+
+// server serves tiles and cluster queries of the datasets.
+type server struct {
+	datasets map[string]*cluster.Cluster
+	// corsOrigin is the value of Access-Control-Allow-Origin header, CORS is disabled if empty
+	corsOrigin string
+	// maxAge of the cached responses in seconds, caching is disabled if not positive
+	maxAge int
+}
+
+// httpError is an error with HTTP status code.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, err: fmt.Errorf(format, args...)}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.corsOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", s.corsOrigin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		s.writeError(w, &httpError{status: http.StatusMethodNotAllowed, err: errMethodNotAllowed})
+
+		return
+	}
+
+	contentType, body, err := s.route(r)
+	if err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+
+	if s.maxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", s.maxAge))
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	var out io.Writer = w
+
+	if acceptsGzip(r) {
+		w.Header().Set("Content-Encoding", "gzip")
+
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+
+		out = gz
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err := out.Write(body); err != nil {
+		log.Printf("unable to write response: %v", err)
+	}
+}
+
+// route handles the request and returns content type and body of the response.
+// Supported paths:
+//
+//	/{dataset}/{z}/{x}/{y}.mvt
+//	/{dataset}/{z}/{x}/{y}.geojson
+//	/{dataset}/clusters?bbox={west},{south},{east},{north}&zoom={z}&limit={limit}
+//	/{dataset}/clusters/{id}/children
+//	/{dataset}/clusters/{id}/leaves?limit={limit}&offset={offset}
+//	/{dataset}/clusters/{id}/expansion-zoom
+//	/{dataset}/clusters/{id}/bounds
+func (s *server) route(r *http.Request) (string, []byte, error) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	c, ok := s.datasets[parts[0]]
+	if !ok {
+		return "", nil, &httpError{status: http.StatusNotFound, err: fmt.Errorf("dataset %q not found", parts[0])}
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "clusters":
+		return s.clusters(c, r)
+	case len(parts) == 4 && parts[1] == "clusters":
+		return s.cluster(c, r, parts[2], parts[3])
+	case len(parts) == 4:
+		return s.tile(c, parts[1], parts[2], parts[3])
+	default:
+		return "", nil, &httpError{status: http.StatusNotFound, err: errNotFound}
+	}
+}
+
+func (s *server) tile(c *cluster.Cluster, zs, xs, file string) (string, []byte, error) {
+	ys, ext := file, ""
+	if i := strings.LastIndexByte(file, '.'); i >= 0 {
+		ys, ext = file[:i], file[i+1:]
+	}
+
+	z, errZ := strconv.Atoi(zs)
+	x, errX := strconv.Atoi(xs)
+	y, errY := strconv.Atoi(ys)
+
+	if errZ != nil || errX != nil || errY != nil {
+		return "", nil, badRequest("invalid tile coordinates %s/%s/%s", zs, xs, ys)
+	}
+
+	switch ext {
+	case "mvt", "pbf":
+		tile, err := c.GetTileMVT(x, y, z, cluster.MVTOptions{LeafProperties: leafProperties})
+		if errors.Is(err, cluster.ErrInvalidTile) {
+			return "", nil, badRequest("%v", err)
+		}
+
+		return contentTypeMVT, tile, err
+	case "geojson":
+		if z < 0 || z > 30 || x < 0 || y < 0 || x >= 1<<uint(z) || y >= 1<<uint(z) {
+			return "", nil, badRequest("%v", cluster.ErrInvalidTile)
+		}
+
+		return s.featureCollection(c, c.GetTileWithLatLng(x, y, z))
+	default:
+		return "", nil, &httpError{status: http.StatusNotFound, err: fmt.Errorf("unsupported tile format %q", ext)}
+	}
+}
+
+func (s *server) clusters(c *cluster.Cluster, r *http.Request) (string, []byte, error) {
+	query := r.URL.Query()
+
+	bbox := strings.Split(query.Get("bbox"), ",")
+	if len(bbox) != 4 {
+		return "", nil, badRequest("bbox parameter must be west,south,east,north")
+	}
+
+	var coordinates [4]float64
+
+	for i := range bbox {
+		v, err := strconv.ParseFloat(strings.TrimSpace(bbox[i]), 64)
+		if err != nil {
+			return "", nil, badRequest("invalid bbox coordinate %q", bbox[i])
+		}
+
+		coordinates[i] = v
+	}
+
+	zoom, err := intParam(query.Get("zoom"), -1)
+	if err != nil || zoom < 0 {
+		return "", nil, badRequest("zoom parameter must be a non-negative integer")
+	}
+
+	limit, err := intParam(query.Get("limit"), -1)
+	if err != nil {
+		return "", nil, badRequest("invalid limit parameter")
+	}
+
+	northWest := &pointfile.Point{Lng: coordinates[0], Lat: coordinates[3]}
+	southEast := &pointfile.Point{Lng: coordinates[2], Lat: coordinates[1]}
+
+	points, err := c.GetClusters(northWest, southEast, zoom, limit)
+	if err != nil {
+		return "", nil, badRequest("%v", err)
+	}
+
+	return s.featureCollection(c, points)
+}
+
+func (s *server) cluster(c *cluster.Cluster, r *http.Request, ids, action string) (string, []byte, error) {
+	id, err := strconv.ParseInt(ids, 10, 64)
+	if err != nil {
+		return "", nil, badRequest("invalid cluster ID %q", ids)
+	}
+
+	switch action {
+	case "children":
+		children, err := c.GetChildren(id)
+		if err != nil {
+			return "", nil, clusterError(err)
+		}
+
+		return s.featureCollection(c, children)
+	case "leaves":
+		return s.leaves(c, r, id)
+	case "expansion-zoom":
+		// GetClusterExpansionZoom doesn't report unknown clusters
+		if _, err := c.GetChildren(id); err != nil {
+			return "", nil, clusterError(err)
+		}
+
+		body, err := json.Marshal(map[string]int{"zoom": c.GetClusterExpansionZoom(id)})
+
+		return contentTypeJSON, body, err
+	case "bounds":
+		bounds, err := c.GetClusterBounds(id)
+		if err != nil {
+			return "", nil, clusterError(err)
+		}
+
+		body, err := json.Marshal(map[string][]float64{
+			"bbox": {bounds.MinLng, bounds.MinLat, bounds.MaxLng, bounds.MaxLat},
+		})
+
+		return contentTypeJSON, body, err
+	default:
+		return "", nil, &httpError{status: http.StatusNotFound, err: errNotFound}
+	}
+}
+
+func (s *server) leaves(c *cluster.Cluster, r *http.Request, id int64) (string, []byte, error) {
+	query := r.URL.Query()
+
+	limit, err := intParam(query.Get("limit"), 10)
+	if err != nil || limit < 1 || limit > maxLeavesLimit {
+		return "", nil, badRequest("limit parameter must be an integer from 1 to %d", maxLeavesLimit)
+	}
+
+	offset, err := intParam(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		return "", nil, badRequest("offset parameter must be a non-negative integer")
+	}
+
+	leaves, err := c.GetLeaves(id, limit, offset)
+	if err != nil {
+		return "", nil, clusterError(err)
+	}
+
+	collection := cluster.FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]cluster.Feature, len(leaves)),
+	}
+
+	for i, leaf := range leaves {
+		p := leaf.(*pointfile.Point)
+		collection.Features[i] = cluster.Feature{
+			Type: "Feature",
+			ID:   &p.ID,
+			Geometry: cluster.Geometry{
+				Type:        "Point",
+				Coordinates: []float64{p.Lng, p.Lat},
+			},
+			Properties: p.Properties,
+		}
+	}
+
+	body, err := json.Marshal(collection)
+
+	return contentTypeGeoJSON, body, err
+}
+
+func (s *server) featureCollection(c *cluster.Cluster, points []cluster.Point) (string, []byte, error) {
+	body, err := json.Marshal(c.ToFeatureCollection(points, cluster.GeoJSONOptions{LeafProperties: leafProperties}))
+
+	return contentTypeGeoJSON, body, err
+}
+
+func (s *server) writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		status = httpErr.status
+	} else {
+		log.Printf("unable to handle request: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// clusterError responds with 404 status to unknown cluster IDs.
+func clusterError(err error) error {
+	if errors.Is(err, cluster.ErrClusterNotFound) {
+		return &httpError{status: http.StatusNotFound, err: err}
+	}
+
+	return err
+}
+
+func leafProperties(p cluster.GeoPoint) map[string]interface{} {
+	return p.(*pointfile.Point).Properties
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(encoding, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// intParam parses integer query parameter, returning def value for empty parameter.
+func intParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/aliakseiz/gocluster/cmd/internal/pointfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*server, *cluster.Cluster) {
+	t.Helper()
+
+	points, err := pointfile.Load("../../testdata/places.json")
+	require.NoError(t, err)
+
+	c, err := cluster.New(pointfile.GeoPoints(points), cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	return &server{
+		datasets:   map[string]*cluster.Cluster{"places": c},
+		corsOrigin: "*",
+		maxAge:     60,
+	}, c
+}
+
+func get(s *server, path string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	return w
+}
+
+type featureCollection struct {
+	Type     string
+	Features []struct {
+		ID         int64
+		Properties map[string]interface{}
+	}
+}
+
+func TestServer_Tiles(t *testing.T) {
+	s, c := newTestServer(t)
+
+	w := get(s, "/places/0/0/0.mvt", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentTypeMVT, w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	expected, err := c.GetTileMVT(0, 0, 0, cluster.MVTOptions{LeafProperties: leafProperties})
+	require.NoError(t, err)
+	assert.Equal(t, expected, w.Body.Bytes())
+
+	w = get(s, "/places/1/1/0.geojson", map[string]string{"Accept-Encoding": "gzip, deflate"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentTypeGeoJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	gz, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	var collection featureCollection
+	require.NoError(t, json.Unmarshal(body, &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Len(t, collection.Features, len(c.GetTileWithLatLng(1, 0, 1)))
+
+	for path, status := range map[string]int{
+		"/places/1/2/0.mvt":      http.StatusBadRequest,
+		"/places/1/0/-1.geojson": http.StatusBadRequest,
+		"/places/a/0/0.mvt":      http.StatusBadRequest,
+		"/places/0/0/0.png":      http.StatusNotFound,
+		"/unknown/0/0/0.mvt":     http.StatusNotFound,
+		"/places/0/0":            http.StatusNotFound,
+	} {
+		w = get(s, path, nil)
+		assert.Equalf(t, status, w.Code, "unexpected status of %s", path)
+		assert.Equal(t, contentTypeJSON, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), `"error"`)
+	}
+}
+
+func TestServer_Clusters(t *testing.T) {
+	s, c := newTestServer(t)
+
+	w := get(s, "/places/clusters?bbox=-180,-85,180,85&zoom=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var collection featureCollection
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &collection))
+	require.NotEmpty(t, collection.Features)
+
+	var clusterID int64
+
+	for _, f := range collection.Features {
+		if f.Properties["cluster"] == true {
+			clusterID = f.ID
+
+			break
+		}
+	}
+
+	require.NotZero(t, clusterID)
+
+	path := "/places/clusters/" + strconv.FormatInt(clusterID, 10)
+
+	w = get(s, path+"/children", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &collection))
+
+	children, err := c.GetChildren(clusterID)
+	require.NoError(t, err)
+	assert.Len(t, collection.Features, len(children))
+
+	w = get(s, path+"/leaves?limit=2&offset=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &collection))
+	require.Len(t, collection.Features, 2)
+	assert.NotEmpty(t, collection.Features[0].Properties["name"])
+
+	w = get(s, path+"/expansion-zoom", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"zoom":`+strconv.Itoa(c.GetClusterExpansionZoom(clusterID))+`}`, w.Body.String())
+
+	bounds, err := c.GetClusterBounds(clusterID)
+	require.NoError(t, err)
+
+	var bbox struct {
+		BBox []float64
+	}
+
+	w = get(s, path+"/bounds", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bbox))
+	assert.Equal(t, []float64{bounds.MinLng, bounds.MinLat, bounds.MaxLng, bounds.MaxLat}, bbox.BBox)
+
+	for p, status := range map[string]int{
+		"/places/clusters":                     http.StatusBadRequest,
+		"/places/clusters?bbox=1,2,3&zoom=1":   http.StatusBadRequest,
+		"/places/clusters?bbox=1,2,3,a&zoom=1": http.StatusBadRequest,
+		"/places/clusters?bbox=1,2,3,4":        http.StatusBadRequest,
+		"/places/clusters/1/children":          http.StatusNotFound,
+		"/places/clusters/1/leaves":            http.StatusNotFound,
+		"/places/clusters/1/expansion-zoom":    http.StatusNotFound,
+		"/places/clusters/1/bounds":            http.StatusNotFound,
+		"/places/clusters/abc/children":        http.StatusBadRequest,
+		path + "/unknown":                      http.StatusNotFound,
+		path + "/leaves?offset=-1":             http.StatusBadRequest,
+		path + "/leaves?limit=-1":              http.StatusBadRequest,
+		path + "/leaves?limit=0":               http.StatusBadRequest,
+		path + "/leaves?limit=1001":            http.StatusBadRequest,
+	} {
+		w = get(s, p, nil)
+		assert.Equalf(t, status, w.Code, "unexpected status of %s", p)
+	}
+}
+
+func TestServer_Methods(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/places/0/0/0.mvt", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/places/0/0/0.mvt", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,191 @@
+// Package pointfile reads points for clustering from GeoJSON and CSV files.
+package pointfile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	cluster "github.com/aliakseiz/gocluster"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported file format, .geojson, .json or .csv expected")
+	ErrNoCoordinates     = errors.New("longitude and latitude columns not found")
+)
+
+// Point is a point read from the file, it implements cluster.GeoPoint interface.
+type Point struct {
+	ID         int64
+	Lng, Lat   float64
+	Properties map[string]interface{}
+}
+
+// GetID returns ID of the point.
+func (p *Point) GetID() int64 {
+	return p.ID
+}
+
+// GetCoordinates returns coordinates of the point.
+func (p *Point) GetCoordinates() *cluster.GeoCoordinates {
+	return &cluster.GeoCoordinates{Lng: p.Lng, Lat: p.Lat}
+}
+
+// Load reads points from the file, format is defined by the file extension.
+func Load(path string) ([]*Point, error) {
+	var read func(io.Reader) ([]*Point, error)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		read = ReadGeoJSON
+	case ".csv":
+		read = ReadCSV
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return read(f)
+}
+
+// GeoPoints converts points to the slice of cluster.GeoPoint.
+func GeoPoints(points []*Point) []cluster.GeoPoint {
+	geoPoints := make([]cluster.GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	return geoPoints
+}
+
+// ReadGeoJSON reads Point features of the GeoJSON feature collection.
+// Numeric feature IDs are used as point IDs, otherwise the feature index is used.
+// Features with other geometry types are skipped.
+func ReadGeoJSON(r io.Reader) ([]*Point, error) {
+	var collection struct {
+		Features []struct {
+			ID       interface{} `json:"id"`
+			Geometry *struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, err
+	}
+
+	points := make([]*Point, 0, len(collection.Features))
+
+	for i, f := range collection.Features {
+		if f.Geometry == nil || f.Geometry.Type != "Point" {
+			continue
+		}
+
+		var coordinates []float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &coordinates); err != nil {
+			return nil, fmt.Errorf("feature %d: invalid coordinates: %w", i, err)
+		}
+
+		if len(coordinates) < 2 {
+			continue
+		}
+
+		p := &Point{
+			ID:         int64(i),
+			Lng:        coordinates[0],
+			Lat:        coordinates[1],
+			Properties: f.Properties,
+		}
+
+		if id, ok := f.ID.(float64); ok {
+			p.ID = int64(id)
+		}
+
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// ReadCSV reads points from CSV with a header row.
+// Longitude is read from "lng", "lon", "longitude" or "x" column and latitude from "lat", "latitude" or "y" column.
+// Optional "id" column is used as point ID, otherwise the row index is used.
+// All other columns are stored as string properties.
+func ReadCSV(r io.Reader) ([]*Point, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	lngIdx, latIdx, idIdx := -1, -1, -1
+
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "lng", "lon", "longitude", "x":
+			lngIdx = i
+		case "lat", "latitude", "y":
+			latIdx = i
+		case "id":
+			idIdx = i
+		}
+	}
+
+	if lngIdx < 0 || latIdx < 0 {
+		return nil, ErrNoCoordinates
+	}
+
+	var points []*Point
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		p := &Point{ID: int64(row - 1), Properties: make(map[string]interface{})}
+
+		if p.Lng, err = strconv.ParseFloat(record[lngIdx], 64); err != nil {
+			return nil, fmt.Errorf("row %d: invalid longitude: %w", row, err)
+		}
+
+		if p.Lat, err = strconv.ParseFloat(record[latIdx], 64); err != nil {
+			return nil, fmt.Errorf("row %d: invalid latitude: %w", row, err)
+		}
+
+		if idIdx >= 0 {
+			if p.ID, err = strconv.ParseInt(record[idIdx], 10, 64); err != nil {
+				return nil, fmt.Errorf("row %d: invalid id: %w", row, err)
+			}
+		}
+
+		for i, value := range record {
+			if i != lngIdx && i != latIdx && i != idIdx {
+				p.Properties[header[i]] = value
+			}
+		}
+
+		points = append(points, p)
+	}
+
+	return points, nil
+}
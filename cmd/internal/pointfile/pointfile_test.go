@@ -0,0 +1,49 @@
+package pointfile_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aliakseiz/gocluster/cmd/internal/pointfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_GeoJSON(t *testing.T) {
+	points, err := pointfile.Load("../../../testdata/places.json")
+	require.NoError(t, err)
+	// one feature has no coordinates
+	assert.Len(t, points, 162)
+	assert.Equal(t, "Niagara Falls", points[0].Properties["name"])
+	assert.Equal(t, -79.04411780507252, points[0].Lng)
+	assert.Equal(t, 43.08771393436908, points[0].Lat)
+}
+
+func TestReadGeoJSON(t *testing.T) {
+	points, err := pointfile.ReadGeoJSON(strings.NewReader(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","id":42,"geometry":{"type":"Point","coordinates":[1,2]},"properties":{"a":1}},
+		{"type":"Feature","id":"b","geometry":{"type":"Point","coordinates":[3,4]}},
+		{"type":"Feature","geometry":{"type":"LineString","coordinates":[[1,2],[3,4]]}}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, &pointfile.Point{ID: 42, Lng: 1, Lat: 2, Properties: map[string]interface{}{"a": 1.0}}, points[0])
+	assert.Equal(t, int64(1), points[1].ID)
+}
+
+func TestReadCSV(t *testing.T) {
+	points, err := pointfile.ReadCSV(strings.NewReader("id,name,Lat,Lng\n7,first,10.5,20.5\n9,second,-1,-2\n"))
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, &pointfile.Point{ID: 7, Lng: 20.5, Lat: 10.5, Properties: map[string]interface{}{"name": "first"}}, points[0])
+	assert.Equal(t, int64(9), points[1].ID)
+
+	_, err = pointfile.ReadCSV(strings.NewReader("name,value\na,b\n"))
+	assert.Equal(t, pointfile.ErrNoCoordinates, err)
+
+	_, err = pointfile.ReadCSV(strings.NewReader("lat,lng\na,b\n"))
+	assert.Error(t, err)
+
+	_, err = pointfile.Load("points.txt")
+	assert.Equal(t, pointfile.ErrUnsupportedFormat, err)
+}
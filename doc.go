@@ -38,12 +38,9 @@
 // All ids of ClusterPoint that you have as result are the index of initial array of Geopoint,
 // so yu could get you point by this index
 //
-// Clusters of points are have autoincrement generated ids, started at ClusterIdxSeed
-// ClusterIdxSeed is the next power of length of input array
-//
-// For example, if input slice of points length is 78,  ClusterIdxSeed == 100,
-// if input slice of points length is 991,  ClusterIdxSeed == 1000
-// etc
+// Clusters of points have generated ids, encoding the zoom level the cluster is created at
+// and the index of the cluster at that zoom level, see DecodeClusterID.
+// Cluster ids never collide with ids of points, regardless of the number of points.
 //
 // TODO: Benchmarks
 //
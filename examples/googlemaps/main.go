@@ -36,7 +36,7 @@ type payload struct {
 }
 
 type payload2 struct {
-	ClusterID int `json:"clusterID"`
+	ClusterID int64 `json:"clusterID"`
 }
 
 var c *cluster.Cluster
@@ -78,7 +78,7 @@ func clustersEndpoint(rw http.ResponseWriter, request *http.Request) {
 		log.Fatal(err)
 	}
 
-	data, err := json.Marshal(points)
+	data, err := json.Marshal(c.ToFeatureCollection(points, cluster.GeoJSONOptions{}))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -7,6 +7,6 @@ import (
 )
 
 func init() {
-	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\xf2\x84\"M\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00index.htmlUT\x05\x00\x01H\x12\x8c[\xecX_o\xe3\xb8\x11\x7f\xdfO1\xf5\xe16\xf2V\x91\x9c\xc3\x01\xb7\xf0Z)n\xb3\xb9^\x8a\xec&\x88S\xdc\xed\x15\xc5\x81\xa2\xc6\x167\x14\xa9\x13\xe9\xd8\xca\"\xdf\xbd\x18Jvl\x8br\x82\x16\xedS\xf9\x90\xc8\xe4pf8\xf3\x9b?\xe4\xe4O\x1f\xae\xcen?_\x9fCn\x0by\xfaj\xd2\xfc\x03\x98\xe4\xc82\xfa\x00\x98\x14h\x19(V`2\xb8\x17\xb8,ue\x07\xc0\xb5\xb2\xa8l2\x10JX\xc1\xe4\xb1\xe1Lbr\x12\x8dBX\x18\xac\xdco\x96JL\x94\x1el3\xe29\xab\x0c\xdad\xb0\xb0\xb3\xe3\xb7\xeb%+\xac\xc4\xd3\x8f\xac\xba\xc3\n\xce\xe4\xc2X\xac\x84\x9aO\xe2f\xa1!2\xb6^\x7f\x03\xc4o\xe0G\xb9d\xb5\x01\x83\x16l\x8eP\xb0\x12r\x14\xf3\xdc\x02\xaeJ)\xb8\xb0\xb2\x06\xab!\xc3\x99P\xe8h\x8cx@\xd03\xf7\x9d\x89\xfb\x96\x17\xbc\x01\x94X\xa0\"F\xcc\xba\xc31\xa1\xcc\x9am\x04o\xe2\x96\xf4\x1b\x92\xf2u\xbd\xaf\x957\x86\x93\xd1\xe8\xdbw\xed\xec\xe3\x93\x86W\xa5\x15Z19\x86\x8f\xec\x0e\x1b~\x86\x15\xa5D(\xd9\x1ca&\xa4t\x93K\xa12\xbd\xdc\x92C\x8e\x08!\xd5Y\xfd\x8c4\x80\x82Us\xa1\xc60z\x9a*Y\x96	5\xdf\x9ak\x94\x9a\xc4\x1b\x1bN\xe2\xb5\x8b'$\xa55q&\xeeAd\xc9\xa0`\xe5\xe0t\x12g\xe2~m{^\x89\xd2\x9e\xae\x05\xbc\x82\x8d\xac\xd9Bq:%\x10\x12>\xb22\x18\xc2\xd7W\x9bU\x1a\xf7\xacr\xceI@\xe1\x12\xe6Z\xcf%F\x05+MD\xe4\x99\xe6\x0b2}4G{\xdex\xe1}}\x91\x05G\x05+\x8f\x86\xe1\xd6\xf1\xd7\xe3A\xebb\x0c\xa3\xb0\xb3\xc0QY\xac\xc6\xf0U2K\x04 \x9d\x0d\x1ew\x08\x1f\x87\xef\xba\xea\xf1\x06r\x06\x12\xf8\xc7?w\x977\xe7cY\xd6\"3\x10Y\x08RsF\xf3\xa1\x03\xd5\xd0\xa3\xa7c\xec=5\xc1<\xe8n\xa0Qj#\x88\xed\xf8I\x80\x97\xae`\xe5\x98\xfe\xf8W\x05'\x0e~	\xe0\x00b\xf3\xf1\x8eR\xd3\xbaH\xb5\xbcf6\x8f\xce.n\xce.\xcf\xfd\x8ci\x10j\xafJ\xc6\x85\xad\xc7pr\x98\xeeLK]\x8d\xe1\xe8\x9b\xd1ht\xd4O\xea\xb2\x07\x01;\x848.\xc5\n\xa5\xf1\xd2>\xfaYH\x96\xa2<t^\xbeVc6\x9b\x1dPc\xa6\x95\xfd\xa5\x0d\xb2\xa3T\xcb\xec\x19\xda\xa9x\xc01\x1c\x9d|_\xae\x0ePZ\\\xd9\xb1CId\xf5\xd4Rb\x0b\x86~\xf2\xc7\xce,\xc1u\x7f\x8eG,\xcb.\x85\xb1\xa8\xb0\n\x8e\xb8\x14\xfc\xee(\xdc 5\xf0\x81\x91\x86\xd4,\xbb\xd5\x7fE\xfb\x9b\xd6\x85\x03\xf1fK\xc6,\xeb\xdb\x06\xce\x80\xcah\x89\x91\xd4\xf3\x86\xb6\x97R\xcc\x1cADQ\nI\x02j!\xe5!\xce\xb0\xc7}\xc0\x99R\xda\xba(\x87BW8\xe8\x97E\xa3B\xbb\xa8T/I\xd7\xa2\xebA\x89\xdd\xb4\xb6\xd8h\xec\xb1\xf6\x1e\xfd\x99K2\x01\xa7|u\xdd\x06k0\xec\xd9\xf7\xd8\xd5\xdd\xeb\xd06\xfdD\xe5\xc2\xe4\x01\xefnj\x0e	|7\x93\xf5$*.\x91Um\xaa2^0l\xe4\xcdtu\xcex\x1epHN{|\xc4\xe9\xd0\x94\xa8\x9d#=\xc7\xe9\xe5\xde$\xd3\x1d\xe2n\xe6]\xe5\x95\xff\x14\x04\xd6\x9ft\xe5\xdcC\x9e	!MC\xe0L\xca\x94\xf1\xbb\xbe|\xabtes\x80\x04\x00\xd2\x94\\\xf4\x89&\xce\x99\xb1\xc10\x92\xcc\x06\x1e\xeb\xd3>d\xc6B\xef>\nX\xff>\xa3\x17\xbb\xf2\xa64\xf1\x0b>'o\x89{\xf2v\xf6\xf9\xe5\x89\x19\x04\xab\xbc\x1a\x92\xcd\"\x96\xea\xca\x06]\xe3\xaf\xf2\xaa-9\xbf~\xbc\xfc\xd9\xda\xf2\x06\xffX8\xb6>\xd2H\x97\xa8\x82\xc1\xf5\xd5\xf4v\x10\xc2 ^\xbb\xce\x13sDm\xd0\xb6\xec~F\x96a\x15\x0c\xce\x9a>\xf0\xf8\xb6.\x918\xb0\x92:/W\xb7\xe2/F\xabw\xeb~\xef\xef\xb7?\x1d\xbf\x1d\xf8\xb0\xbf\x15\xfbi\xda\x95K\xd6*YMp\x80\xa4\x07\xa3MG\xe0P\xe2]O\xd3C\xc5A-\x0f\xad\x82\xab/v\xdc@\xab?\xcb;:\xea7\xc8\xb5\xa1\xeb\x91zFO\x11\xa3a\xf0E\xaa8\xd4\xbd@\x15Bu?Y\x8f\x1e\x9e\x1a\xd4=\x8b\xc3\x8e\xaa\x90e\xb5\xb1\xcc\"\xcf\x99\x9a#$[E\xc8\x7f\x8e\x16\xc4\x91\xdb:\xa5\xadI\xf2=\xbc~\xdd\xe0\xcb2\xbb0I\xf2\xddht\xa0d\xac\x93a\x9b\n\x82\xbfM\xaf>E%\xc1\xac\xe5lJ\xad\x0c\xde\xe2\xca\x0e\xfd\xb5\xc3s\xc4\x1e\xbc\xab\xacao\\\xd9\x16\xb3:h\xb1\xe8\xcb\xf8;e\x8c\x88\x84\x9a\xc3LWM-\xfb6\x1b\x84\xeekx('\xee\xe4\xbf\xa7b\xdd\x06\xe6\xc5\x87\xe7S\xe0\x7f\x96\x01H\xc1\xffa\xf4\xbf$\xba7g\x1f?}v\xa1\xfb\x7f\x90\xfe\x1b \x9d\xa3\xb5\x04R\x07PB\xaa\x03\xe9\xc6\xca/Cj\xba\x102\xdb\xf4\x1b}\x9d\xe4^W\xd2Yw-\xd8\xba\x1f\xc1\xfe~\x84\x10#\x99\x95j\xee\xb9Z]2{\xa9\xe6\x01F\x9fC\xc0\xe8W\xbfa\xb7.r\x18QD5\xech\xc7\xa7Eq\xad\x85\xb2\xe6\xd9F\xe7\xf1\xd5\xae5\xe2\xb8\xb9\xdd\x17Z\xdb\xbc\xb5gk \xbf\xd5\x1aJ\nn\x08\xe8\x1e\x07\x05[\x85\xc0\x95\xf5\x19\x8f\x00\xc9\x95\x85\xd3\x84\xc8\xfa`\xd7`\xae\xeb\xf2.nP\x1a\xec\xab\xa5\x90\xec\x98\x14\xef\xe9r\xbe}\xe1p\xda\x1e\xd1\x11\x7fo\"*\xdb\xbe|8\xfa\x9e\xb8\xa2\xd1\xe5]a\xa1\xefq\xc3\xfe\xe1@\x0f\xfed\xb4]\x9b\xc1\x9f\xe1\xa4\xb7\x05\xf7\xcf\x1b\xb4\xb7\xa2@\xbd\xb0\xc1V:\xd8\xbe\x14\x90/\x1eCx;\x1a\xbe#\xf7\xbe\x1d\x15\x06\x84\x81e\xce,\\\xc0L/T\x06V\xc3RWw\xb0D)A+(j0\xb5\xb1X\xc0\xf11\x08\x0b\x85{\x8f\xa2\xfb\xcc\x0e\x19\x93\xb2\xa5\xeb\xdes\xf7\x9e+\x00\xf6\x90\xf6\x12\xe7\xa4\xa4\x9c\xf1\xb9\xc7\x87\x9d\xedV\x9b\x0c0o\x0d0\x0c\xa1\xfd\xf9\xde\xf1\xa3\x89\x9d`\xdf\x0b\x89\xfd\x97\x15\xaf\x89\xff\x8b\xf2C\xa0\x9c\xbc\x99k\xf3\xd5$n\x1f\xaf\x9a_\xcd\x0f`\xa6V\x1c2\x9cas\xff0\x15O\x06\xb9\xb5\xa5\x19\xc7\xb1\xb3mcgV\n\x13q]\xb8\xb9\x98\x95\"\xfeb\xfer\x87u\xf2\xe3\xc5\x03\x9b\xd6\x1fN\xde\xaf\xcc\x0d\xbf\xf8\xbd<\x99\x8e\xbe\xfb\xe1\xe6\x9a\x7f\xb9\xfe\x92\x95\xa6\xfe\xeds*o~\xf8\xe3\xe2\xf5\xba\x02$\xed\xfb\xd8\xfa\xc9sK\xabI\xdc<\xc1Mb\xf7\xfe\xfa\xaf\x00\x00\x00\xff\xffPK\x07\x08Q\xafb\xb6Z\x06\x00\x00\x96\x15\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\xf2\x84\"MQ\xafb\xb6Z\x06\x00\x00\x96\x15\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00index.htmlUT\x05\x00\x01H\x12\x8c[PK\x05\x06\x00\x00\x00\x00\x01\x00\x01\x00A\x00\x00\x00\x9b\x06\x00\x00\x00\x00"
+	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\xeb\xb6Q]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00index.htmlUT\x05\x00\x01\xdb\xfc\xd3j\xecX_o\xe36\x12\x7f\xcf\xa7\x98S\xd1\x8d\xb4\xa7HNQ\xa0\x0b\xc7\xf2\xa1\x9bM\xaf9d\x9b \xce\xa1\xd7.\x16\x0b\x8a\x1aY\xdcP\xa4*\xd2\xb1\x95]\x7f\xf7\xc3\xc8\x92c\xc7\x92\x1d\xe0\xfe<u\x1e\x12\x99\x1c\xce\xdf\x1f\x87C\x8e\xfe\xf2\xee\xfa\xfc\xee\xb7\x9b\x0b\xc8l.\xc7G\xa3\xd5?\x80Q\x86,\x19\x1f\x01\x00\x8cr\xb4\x0c\x14\xcb1r\x1e\x04\xce\x0b]Z\x07\xb8V\x16\x95\x8d\x1c\xa1\x84\x15L\x9e\x18\xce$F\xa7\xc1\xc0\x87\x99\xc1\xb2\xfe\xcdb\x89\x91\xd2\xce\xa6 \x9e\xb1\xd2\xa0\x8d\x9c\x99MO\xde\xb4SVX\x89\xe3\xf7\xac\xbc\xc7\x12\xce\xe5\xccX,\x85\x9a\x8e\xc2\xd5\xc4j\xbd\xb1U\xfb\x0d\x10\xbe\x86\x1f\xe5\x9cU\x06\x0cZ\xb0\x19B\xce\n\xc8PL3\x0b\xb8(\xa4\xe0\xc2\xca\n\xac\x86\x04S\xa1\xb0\xe61\xe2\x11A\xa7\xf5w\"\x1e\x1aY\xf0\x1aPb\x8e\x8a\x041[;\xc7\x842\xad\xd8\x00^\x87\x0d\xeb7\xa4\xe5K\xbb\xae\xd17\x84\xd3\xc1\xe0\xdb\xb3ft\xd9\xfc\x0f_\xc3ua\x85VL\x0e\xe1=\xbb\xc7\x95<\xc3\xf2B\"\x14l\x8a\x90\n)\xeb\xc1\xb9P\x89\x9eo\xe8\xa1D\xf8\x10\xeb\xa4:\xa0\x0d g\xe5T\xa8!\x0c\x9e\x86\n\x96$BM7\xc6VF\x8d\xc2u\x0cGa\x9b\xe2\x11iiB\x9c\x88\x07\x10I\xe4\xe4\xacp\xc6\xa30\x11\x0fm\xecy)\n;n\x15\x1c\xc1ZW:S\x9c\xbc\x04B\xc2{V\xb8\x1e|9Z\xcf\x12=\xb0\xb2NN\x04\n\xe70\xd5z*1\xc8Ya\x02bO4\x9fQ\xe8\x83)\xda\x8bU\x16\xdeV\x97\x89{\x9c\xb3\xe2\xd8\xf37\xdco\xe9Q\xeb|\x08\x03\x7fg\x82\xa3\xb2X\x0e\xe1\x8bd\x96\x18@\xd61h\x13\xd2D\xc2;\xdb5\x8f\xaf g \x82\x0f\x1f\xb7\xa7\xd7\xfe\xb1$i\x90\xe9\x8a\xc4\x07\xa99#\xbf\xfd\x1aT^\x87\x9d\xe47\xef\xf4\x9a`\xee\xee. *\xb4\x11$v\xf8\xa4\xa0\x93/g\xc5\x90\xfet\xcf\n\xae\xd5\xb0\xc3\xa4\x96\nf\xb3\xe1V*&U\x1eky\xc3l\x16\x9c_\xde\x9e_]t\x0b&\"\xd4^\x17\x8c\x0b[\x0d\xe1t?\xdf\xb9\x96\xba\x1c\xc2\xf17\x83\xc1\xe0\xb8\x9f\x95\xaa\x05\xd26\xf2!\x0c\x0b\xb1@i:y\x97\xdd\"$\x8bQ\xee\xf3\x97\xb7f\xa4i\xba\xc7\x8cT+\xfbk\xb3\xa5\x8fc-\x93\x03\xbc\x13\xf1\x88C8>\xfd\xbeX\xec\xe1\xb4\xb8\xb0\xc3\x1a%\x81\xd5\x13K\x85\xcd\xf5\xba\xd9\xb7\xa1\xda\xc2u\x07\xe7\x01K\x92+a,*,\xddc.\x05\xbf?\xf6\xd7Hu\xbb\xc0H$5K\xee\xf4\xdf\xd1\xfe\xaeu^\x83x\xbd$a\x96\xf5-#\xe2Z\x19-1\x90z\xba\xe2\xdd1\xaa%\x91\xd6\x0c\x01\xedR\x88\"P3)\xf7I~.\xdd\xe1L)m\xeb]\x0e\xb9.\xd1\xe9\xd7ET\xa2\x9d\x95\xaa\x97e\xd9;\x93\xb3\"0M,\xd6\x16{g\x87\xf8\xcf\xeb\"\xe3r\xaaW7\xcdfu\xbd\x9eu\xcb]\xdb\x97\x1d\xacm\xf9	\x8a\x99\xc9\\\xbe\xbbh\xe5$\xf0\xad\x89eO\xa1\xe2\x12Y\xd9\x94*\xd3	\x86\xb5\xbeT\x97\x17\x8cg.\x87h\xdc\x93#NA\xa2B]'\xf2\xe8\x05\x1e\xb6\xd2W\xc5t\x8f\xc5T \x17Y\xd9\xed\x05\x81\xf5']\xd6P%,\xf8\x10\xc7>p&e\xcc\xf8}\x97W$N\xe9\xd2f\x00\x11\x00\xc41\xa5\xe8\x17\x1a\xb8`\xc6\xba^ \x99u;\xa2O\xeb\x90\x19\x0b\xbd\xeb\xd4\xb4o\x9d\xd1\xb3m}\x13\x1a\xf8\x15\x0f\xe9\x9b\xe33}[\xeb\xba\xf5\x89\x14\xdcEVz\x14\xb3\x80\xc5\xba\xb4\xeen\xf0\x17Y\xd9\x1c9\xffz\x7f\xf5\xb3\xb5\xc5-\xfe1C\xd3\xc3\x1a\xe8\x02\x95\xeb\xdc\\O\xee\x1c\x1f\x9c\xb0M]\xc7\x9e#\xa5\x06m#\xeegd	\x96\xaes\xbe\xea\x03O\xee\xaa\x02I\x02+\xa8\xf3\xaa\x0f\xc6\xf0\xb3\xd1\xea\xac\xed\xf7\xfey\xf7\xd3\xc9\x1b\xa7#\x8a\x9b\x95%\x8ew\xf5Rv\nV\x11\x1c \xea\xc8:\x11\xe1cX\xff\xed.\xabq\xbc\xefpP\xf3}\xb3Du?QC\xab[~Ku\xbfA\xa9\xf5\x01\xe0\xe8\xd9\xe4\xa1C\x8c\xc8\xe0\x8bL\xa9Q\xf7\x02S\x08\xd5\xfdl=\x87i\xc7\x19\xb4\xeb\x0b\xa1A\xab\x12YR\x19\xcb,\xf2\x8c\xa9)B\xb4q\x08u\xfb\xd1\x808\xa8\x97Nhi\x14}\x0f\xaf^\xd5\x12I\xd4\xccD\xd1w\x83A\xd7\x06\x7fV\x0c\x9bR\xe0\xfecr\xfdKP\xd0\xb5\xa2\x91l\n\xad\x0c\xde\xe1\xc2z\xbbx\xeaqqg\x84D\x19T\xc9J\xbc\xa9\x8fm\x91Vn\x83E\xef\x00\x94\x1db\x12j\n\xa9.kd\xc2\xb7\x89\xe3\xd7_\xde\x8b\xaa\xf8\xf6a\xddl\xcc\xcbw\x87K\xe0\x7fV\x01\xc8\xc0\xff\xe3\xee\x7f\xc9\xee^\xfb>|\xfa\xf4\xff\x04\xe9\x7f\x03\xa4S\xb4\x96@JY\xaf\x91Z\x83t\x1de\xefE\xfdF<\x13\xb2\xbd\x1a\x99\xdeN\xf2YW\xb23O\xeb\x82\x14\x99\x9d\x95\xf8\xd4\x98\xa4\xfd\x8d	A\x87k]&B1\x8b\xd4m\xa4\xc1\x14u\x8e\xb6\xac\x82\x8d\x89\xde\xc5\x92Y\xa9\xa6\x1d\x17\xb4+f\xaf\xd4\xd4\xdd\x90\xf1\xe1\xf4\xa3\xbf\xa9\xec\xc3\xe0\xe3\xae\x07D\x1b\x97\xc44\xa0\x0e{\xa5\xc4\x874(J]`i\x05\x9a\xa0\xd0B\xd9O\\\xcf\x94\xfd\xc4\xe2\xb8\xc4\x07\xc1,&\xf0\xf5+\x9czG\x07\x9a\xc8\xe5\xd1v\x1e\xc2\xb0~B0\xb9\xd66k2\xd9\xa4f\x8b\xaf\x1dl8\xa9\xac\x80K7H\xc8\xd9\xc2\x07\xaelW\xdah+pea\x1c\x11[\x17\xc7S\x7fz\xf6\x82\xb2\x8a\xd2`\x8f\x90G\x88\xb6\xae\xa4\xf8@\xcf\x02\x9bW\x9d\xda\xdacr\xf1\xd3\xea\xc0I6\xaf=5\x7f\xcf\xb1C\xb4+\xbb\xc4\\?\xe0\xfa&\xf5\xd8Q\xd3[z\n\xdav\xcc\xe0\xafp\xda\xb3\xac\xab\xd3'2h\xefD\x8ezf\xdd6'\xae\xf7e\xf3:B\xb9X\xfa\xf0f\xe0\x9dQz\xdf\x0cr\x03\xc2\xc0\x9c\x1e\xa7.!\xd53\x95\xd0\xc3\xd6\\\x97\xf70G)A+\xc8+0\x95\xb1\x98\xc3\xc9	\x08\x0b9]c\x81nR[lL\xca\x86\xcf\x1c\xc8\xd6\x12\xe0\x19\xd2^\x92\x9c\x98\x8c3]\xe9\xe9\xc2\xcef\x93O\x01\x986\x01\xf0\x08\x94\xf5\xcf\xb7\xb5<\x1a\xd8*3\xde\xfe7\x9d\xce\x10\xff\x0f\xf5\xfb@-\xcb\xd1\xb3\x9b\xd9(l\x9e\xcd6\xdf\xd0\x80\x99Jqz\x94\xc4\xd5\xcd\xc7\x94<r2k\x0b3\x0c\xc3\xfaYl\x15gV\x08\x13p\x9d\xd7c!+D\xf8\xd9\xfc\xed\x1e\xab\xe8\xc7\xcbG6\xa9\xde\x9d\xbe]\x98[~\xf9\xa98\x9d\x0c\xbe\xfb\xe1\xf6\x86\x7f\xbe\xf9\x9c\x14\xa6\xfa\xfd\xb7X\xde\xfe\xf0\xc7\xe5\xab\xf6\xec\x89\x9a\x979g\xbcc\xd5(\\=\xfe\x8d\xc2\xcc\xe6r\xfc\xef\x01\x00PK\x07\x08\x8a\x86\x8b\xd3\x9d\x06\x00\x00\x10\x16\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\xeb\xb6Q]\x8a\x86\x8b\xd3\x9d\x06\x00\x00\x10\x16\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00index.htmlUT\x05\x00\x01\xdb\xfc\xd3jPK\x05\x06\x00\x00\x00\x00\x01\x00\x01\x00A\x00\x00\x00\xde\x06\x00\x00\x00\x00"
 	fs.Register(data)
 }
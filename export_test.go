@@ -0,0 +1,6 @@
+package cluster
+
+// Untyped returns the Cluster of the typed cluster, so tests could compare it with other clusters.
+func Untyped[T any](c *TypedCluster[T]) *Cluster {
+	return c.cluster
+}
@@ -0,0 +1,136 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+
+	"github.com/electrious-go/kdbush"
+)
+
+// GetClustersFiltered returns clusters of the zoom level within the area, the same as GetClustersWithContext,
+// but only the original points, matching the filter, are clustered.
+// Matching points in and around the area are clustered on each call from the MaxZoom level down to the zoom level,
+// the same way as New clusters them, so a separate Cluster for each filter is not needed.
+// Points are taken within the sum of clustering diameters of the zoom levels around the area,
+// so clusters match the Cluster of the matching points, except rare differences next to the area borders,
+// caused by the clustering order of points outside of it. Clusters of other algorithms than Greedy, e.g. DBSCAN
+// clusters, chained through the points outside of the taken ones, could differ next to the area borders as well.
+// Single points keep their IDs, while clusters are not part of the clusters hierarchy,
+// so their IDs are marked and GetChildren, GetLeaves and other methods return ErrClusterNotFound for them.
+// With stable IDs clusters have the same IDs as in the Cluster of the matching points, see WithStableIDs.
+func (c *Cluster) GetClustersFiltered(ctx context.Context, northWest, southEast GeoPoint, zoom int,
+	filter func(p GeoPoint) bool,
+) ([]Point, error) {
+	return c.getClustersFilteredWithin(ctx, northWest, southEast, zoom, func(i int) bool {
+		return filter(c.Points[i])
+	})
+}
+
+// getClustersFilteredWithin clusters the original points, matching by their positions, within the area.
+func (c *Cluster) getClustersFilteredWithin(ctx context.Context, northWest, southEast GeoPoint, zoom int,
+	match func(i int) bool,
+) ([]Point, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	boxes, err := c.worldBoxes(northWest, southEast)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Point
+
+	for _, box := range boxes {
+		points, err := c.getClustersFiltered(ctx, box, c.LimitZoom(zoom), match)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, points...)
+	}
+
+	return result, nil
+}
+
+// getClustersFiltered clusters the matching original points around the box and returns clusters within it.
+func (c *Cluster) getClustersFiltered(ctx context.Context, box worldBox, zoom int, match func(i int) bool,
+) ([]Point, error) {
+	var buffer float64
+	for z := zoom; z <= c.MaxZoom; z++ {
+		buffer += 2 * c.worldRadius(z)
+	}
+
+	leaves := c.Indexes[len(c.Indexes)-1]
+	ids := c.rangeLevel(len(c.Indexes)-1, worldBox{
+		minX: box.minX - buffer,
+		minY: box.minY - buffer,
+		maxX: box.maxX + buffer,
+		maxY: box.maxY + buffer,
+	})
+	points := make([]*Point, 0, len(ids))
+
+	for _, i := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		p := leaves.Points[i].(*Point)
+		if match(int(p.ID)) {
+			cp := *p
+			cp.parentID = 0
+			points = append(points, &cp)
+		}
+	}
+	// points are clustered in the order of the original points, same as New does
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].ID < points[j].ID
+	})
+
+	filtered := &Cluster{
+		MinZoom:   zoom,
+		MaxZoom:   c.MaxZoom,
+		TileSize:  c.TileSize,
+		NodeSize:  c.NodeSize,
+		MinPoints: c.MinPoints,
+		Indexes:   make([]*kdbush.KDBush, c.MaxZoom-zoom+2),
+		reduceFn:  c.reduceFn,
+		radiusFn:  c.Radius,
+		stableIDs: c.stableIDs,
+		clusters:  make(map[int64]int),
+
+		clusterLevels: make([]levelClusters, c.MaxZoom-zoom+1),
+
+		weightedSeeds: c.weightedSeeds,
+		centroid:      c.centroid,
+		leafPriority:  c.leafPriority,
+		algorithm:     c.algorithm,
+		projection:    c.projection,
+	}
+
+	for z := c.MaxZoom; z >= zoom; z-- {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		filtered.Indexes[z+1-zoom] = kdbush.NewBush(clustersToPoints(points), c.NodeSize)
+		points = filtered.clusterize(points, z)
+	}
+
+	result := make([]Point, 0, len(points))
+
+	for _, p := range points {
+		if p.X >= box.minX && p.X <= box.maxX && p.Y >= box.minY && p.Y <= box.maxY {
+			point := c.geoPoint(p)
+			// IDs of the clusters, unless they are stable, are indexes of the filtered clusters,
+			// which would match unrelated clusters of the hierarchy
+			if point.IsCluster(c) && !c.stableIDs {
+				point.ID |= filteredFlag
+			}
+
+			result = append(result, point)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package cluster_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// describePoints returns sorted descriptions of the points, identifying single points by their GeoPoint IDs.
+func describePoints(points []cluster.Point, geoPoints []cluster.GeoPoint) []string {
+	descriptions := make([]string, len(points))
+
+	for i, p := range points {
+		id := p.ID
+		if p.NumPoints == 1 {
+			id = geoPoints[p.ID].GetID()
+		}
+
+		descriptions[i] = fmt.Sprintf("%d %d %.9f %.9f %v", id, p.NumPoints, p.X, p.Y, p.Properties)
+	}
+
+	sort.Strings(descriptions)
+
+	return descriptions
+}
+
+func TestCluster_GetClustersFiltered(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+	even := func(p cluster.GeoPoint) bool {
+		return p.GetID()%2 == 0
+	}
+
+	var filtered []cluster.GeoPoint
+
+	for _, p := range geoPoints {
+		if even(p) {
+			filtered = append(filtered, p)
+		}
+	}
+
+	sum := cluster.WithAggregator(
+		func(p cluster.GeoPoint) interface{} {
+			return 1
+		},
+		func(accumulated, props interface{}) interface{} {
+			return accumulated.(int) + props.(int)
+		})
+	opts := []cluster.Option{cluster.WithinZoom(0, 16), cluster.WithStableIDs(), cluster.WithMinPoints(3), sum}
+
+	c, err := cluster.New(geoPoints, opts...)
+	require.NoError(t, err)
+
+	expected, err := cluster.New(filtered, opts...)
+	require.NoError(t, err)
+
+	world := []cluster.GeoPoint{&cluster.Point{X: -180, Y: 90}, &cluster.Point{X: 180, Y: -90}}
+	area := []cluster.GeoPoint{&cluster.Point{X: -20, Y: 60}, &cluster.Point{X: 40, Y: 20}}
+
+	for z := 0; z <= 17; z++ {
+		actual, err := c.GetClustersFiltered(context.Background(), world[0], world[1], z, even)
+		require.NoError(t, err)
+		assert.Equal(t, describePoints(expected.AllClusters(z, -1), filtered), describePoints(actual, geoPoints), "zoom %d", z)
+
+		actual, err = c.GetClustersFiltered(context.Background(), area[0], area[1], z, even)
+		require.NoError(t, err)
+
+		expectedArea, err := expected.GetClusters(area[0], area[1], z, -1)
+		require.NoError(t, err)
+		assert.Equal(t, describePoints(expectedArea, filtered), describePoints(actual, geoPoints), "area at zoom %d", z)
+	}
+
+	// area crossing the antimeridian
+	actual, err := c.GetClustersFiltered(context.Background(), &cluster.Point{X: 170, Y: 80}, &cluster.Point{X: -170, Y: -80}, 3, even)
+	require.NoError(t, err)
+
+	expectedArea, err := expected.GetClusters(&cluster.Point{X: 170, Y: 80}, &cluster.Point{X: -170, Y: -80}, 3, -1)
+	require.NoError(t, err)
+	assert.Equal(t, describePoints(expectedArea, filtered), describePoints(actual, geoPoints))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = c.GetClustersFiltered(ctx, world[0], world[1], 3, even)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestCluster_GetClustersFilteredIDs(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	nw, se := &cluster.Point{X: -180, Y: 90}, &cluster.Point{X: 180, Y: -90}
+
+	points, err := c.GetClustersFiltered(context.Background(), nw, se, 3, func(p cluster.GeoPoint) bool {
+		return p.GetID()%2 == 0
+	})
+	require.NoError(t, err)
+
+	ids := make(map[int64]bool)
+	for _, p := range c.AllClusters(3, -1) {
+		ids[p.ID] = true
+	}
+
+	clusters := 0
+
+	for _, p := range points {
+		if !p.IsCluster(c) {
+			continue
+		}
+
+		clusters++
+		zoom, _, isCluster := cluster.DecodeClusterID(p.ID)
+		assert.True(t, isCluster)
+		assert.GreaterOrEqual(t, zoom, 3)
+		// filtered clusters are not part of the hierarchy, so their IDs never match its clusters
+		assert.False(t, ids[p.ID], "cluster %d", p.ID)
+
+		_, err := c.GetChildren(p.ID)
+		assert.Equal(t, cluster.ErrClusterNotFound, err)
+
+		_, err = c.GetLeaves(p.ID, -1, 0)
+		assert.Equal(t, cluster.ErrClusterNotFound, err)
+
+		_, err = c.GetClusterBounds(p.ID)
+		assert.Equal(t, cluster.ErrClusterNotFound, err)
+
+		assert.Equal(t, c.MaxZoom, c.GetClusterExpansionZoom(p.ID))
+	}
+
+	assert.Greater(t, clusters, 0)
+}
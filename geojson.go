@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+)
+
+// FeatureCollection is a GeoJSON feature collection of clusters and points.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature of a cluster or a point.
+// ID is nil for features, which are not identified, e.g. cluster hulls, so feature IDs of the collection are unique.
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         *int64                 `json:"id,omitempty"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry.
+// Coordinates are [lng, lat] for Point geometry and a list of closed rings of [lng, lat] for Polygon geometry.
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// GeoJSONOptions configures GeoJSON encoding.
+type GeoJSONOptions struct {
+	// Properties converts Point.Properties, created with aggregator, into the feature properties.
+	// If not set, Point.Properties of map[string]interface{} type are used as is, any other type is ignored.
+	Properties func(properties interface{}) map[string]interface{}
+	// LeafProperties returns properties of the original point, used for features of single points.
+	// If not set, single points have the aggregated properties only.
+	LeafProperties func(p GeoPoint) map[string]interface{}
+	// Hulls adds Polygon features of convex hulls of the clusters after the point features, see GetClusterHull.
+	// Hull features have the cluster ID, "cluster_id" and "hull" properties.
+	// Clusters of points at the same position or on a line have no hull feature.
+	Hulls bool
+}
+
+// ToFeatureCollection converts points with Lng/Lat coordinates, returned by GetClusters, AllClusters,
+// GetChildren or GetTileWithLatLng, into the GeoJSON feature collection.
+// Clusters have the same properties as mapbox/supercluster clusters:
+// "cluster", "cluster_id", "point_count" and "point_count_abbreviated", followed by the aggregated properties.
+// Grouped points and clusters have "group" property, unless LeafProperties are used for points.
+// ID of the cluster feature is the cluster ID, ID of the point feature is ID of the original point.
+func (c *Cluster) ToFeatureCollection(points []Point, opts GeoJSONOptions) FeatureCollection {
+	features := make([]Feature, len(points))
+
+	for i := range points {
+		features[i] = c.toFeature(points[i], opts)
+	}
+
+	if opts.Hulls {
+		for i := range points {
+			if hull, ok := c.hullFeature(points[i]); ok {
+				features = append(features, hull)
+			}
+		}
+	}
+
+	return FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: features,
+	}
+}
+
+func (c *Cluster) toFeature(p Point, opts GeoJSONOptions) Feature {
+	id := p.ID
+	feature := Feature{
+		Type: "Feature",
+		ID:   &id,
+		Geometry: Geometry{
+			Type:        "Point",
+			Coordinates: []float64{p.X, p.Y},
+		},
+	}
+
+	if !p.IsCluster(c) && opts.LeafProperties != nil {
+		original := c.leaf(int(p.ID))
+		id = original.GetID()
+		feature.Properties = opts.LeafProperties(original)
+
+		return feature
+	}
+
+	var aggregated map[string]interface{}
+
+	if opts.Properties != nil {
+		aggregated = opts.Properties(p.Properties)
+	} else if m, ok := p.Properties.(map[string]interface{}); ok {
+		aggregated = m
+	}
+
+	feature.Properties = make(map[string]interface{}, len(aggregated)+4)
+
+	for key, value := range aggregated {
+		feature.Properties[key] = value
+	}
+
+	if p.Group != "" {
+		feature.Properties["group"] = p.Group
+	}
+
+	if p.IsCluster(c) {
+		feature.Properties["cluster"] = true
+		feature.Properties["cluster_id"] = p.ID
+		feature.Properties["point_count"] = p.NumPoints
+		feature.Properties["point_count_abbreviated"] = abbreviateCount(p.NumPoints)
+	} else {
+		id = c.leafID(int(p.ID))
+	}
+
+	return feature
+}
+
+// hullFeature returns Polygon feature of the convex hull of the cluster, if it has at least three vertices.
+func (c *Cluster) hullFeature(p Point) (Feature, bool) {
+	if !p.IsCluster(c) {
+		return Feature{}, false
+	}
+
+	hull, err := c.hull(p.ID)
+	if err != nil || len(hull) < 3 {
+		return Feature{}, false
+	}
+
+	ring := make([][]float64, len(hull)+1)
+
+	for i, v := range hull {
+		coordinates := c.projection.Unproject(v.X, v.Y)
+		ring[i] = []float64{coordinates.Lng, coordinates.Lat}
+	}
+
+	ring[len(hull)] = ring[0]
+
+	// hull has no ID, as the cluster feature has the same ID
+	return Feature{
+		Type: "Feature",
+		Geometry: Geometry{
+			Type:        "Polygon",
+			Coordinates: [][][]float64{ring},
+		},
+		Properties: map[string]interface{}{
+			"cluster_id": p.ID,
+			"hull":       true,
+		},
+	}, true
+}
+
+// abbreviateCount returns points count in the same format as mapbox/supercluster does,
+// e.g. 1.2k for 1234 points and 57k for 56789 points.
+func abbreviateCount(count int) interface{} {
+	if count >= 10000 {
+		return fmt.Sprintf("%.0fk", math.Round(float64(count)/1000))
+	}
+
+	if count >= 1000 {
+		return fmt.Sprintf("%gk", math.Round(float64(count)/100)/10)
+	}
+
+	return count
+}
@@ -0,0 +1,151 @@
+package cluster_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_ToFeatureCollection(t *testing.T) {
+	points := importData("./testdata/places.json")
+	assert.NotEmptyf(t, points, "no points for clustering")
+
+	geoPoints := make([]cluster.GeoPoint, len(points))
+
+	for i := range points {
+		points[i].ID = int64(i + 1000)
+		geoPoints[i] = points[i]
+	}
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	result := c.AllClusters(2, -1)
+	collection := c.ToFeatureCollection(result, cluster.GeoJSONOptions{
+		LeafProperties: func(p cluster.GeoPoint) map[string]interface{} {
+			return map[string]interface{}{"name": p.(*TestPoint).Properties.Name}
+		},
+	})
+
+	raw, err := json.Marshal(collection)
+	require.NoError(t, err)
+
+	var decoded struct {
+		Type     string
+		Features []struct {
+			Type     string
+			ID       int64
+			Geometry struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, "FeatureCollection", decoded.Type)
+	require.Len(t, decoded.Features, len(result))
+
+	for i, f := range decoded.Features {
+		p := result[i]
+		assert.Equal(t, "Feature", f.Type)
+		assert.Equal(t, "Point", f.Geometry.Type)
+		assert.Equal(t, []float64{p.X, p.Y}, f.Geometry.Coordinates)
+
+		if p.IsCluster(c) {
+			assert.Equal(t, int64(p.ID), f.ID)
+			assert.Equal(t, true, f.Properties["cluster"])
+			assert.Equal(t, float64(p.ID), f.Properties["cluster_id"])
+			assert.Equal(t, float64(p.NumPoints), f.Properties["point_count"])
+			assert.Equal(t, float64(p.NumPoints), f.Properties["point_count_abbreviated"])
+		} else {
+			assert.Equal(t, points[p.ID].ID, f.ID)
+			assert.Equal(t, map[string]interface{}{"name": points[p.ID].Properties.Name}, f.Properties)
+		}
+	}
+}
+
+func TestCluster_ToFeatureCollection_Abbreviated(t *testing.T) {
+	tests := []struct {
+		count    int
+		expected interface{}
+	}{
+		{count: 999, expected: 999},
+		{count: 1234, expected: "1.2k"},
+		{count: 2000, expected: "2k"},
+		{count: 56789, expected: "57k"},
+	}
+
+	for _, tt := range tests {
+		geoPoints := make([]cluster.GeoPoint, tt.count)
+		for i := range geoPoints {
+			geoPoints[i] = simplePoint{int64(i), 10, 10}
+		}
+
+		c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 2))
+		require.NoError(t, err)
+
+		collection := c.ToFeatureCollection(c.AllClusters(0, -1), cluster.GeoJSONOptions{})
+		require.Len(t, collection.Features, 1)
+		assert.Equal(t, tt.expected, collection.Features[0].Properties["point_count_abbreviated"])
+	}
+}
+
+func TestCluster_ToFeatureCollection_Hulls(t *testing.T) {
+	points := importData("./testdata/places.json")
+	assert.NotEmptyf(t, points, "no points for clustering")
+
+	geoPoints := make([]cluster.GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	result := c.AllClusters(2, -1)
+	collection := c.ToFeatureCollection(result, cluster.GeoJSONOptions{Hulls: true})
+
+	hulls := 0
+
+	for _, p := range result {
+		if !p.IsCluster(c) {
+			continue
+		}
+
+		hull, err := c.GetClusterHull(p.ID)
+		require.NoError(t, err)
+
+		if len(hull) < 3 {
+			continue
+		}
+
+		f := collection.Features[len(result)+hulls]
+		hulls++
+
+		// hull has no ID, so IDs of the collection features are unique
+		assert.Nil(t, f.ID)
+		assert.Equal(t, "Polygon", f.Geometry.Type)
+		assert.Equal(t, map[string]interface{}{"cluster_id": p.ID, "hull": true}, f.Properties)
+
+		rings := f.Geometry.Coordinates.([][][]float64)
+		require.Len(t, rings, 1)
+		require.Len(t, rings[0], len(hull)+1)
+		assert.Equal(t, rings[0][0], rings[0][len(hull)])
+
+		for i, v := range hull {
+			assert.Equal(t, []float64{v.Lng, v.Lat}, rings[0][i])
+		}
+	}
+
+	assert.NotZero(t, hulls)
+	assert.Len(t, collection.Features, len(result)+hulls)
+
+	data, err := json.Marshal(collection.Features[len(result)])
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"id"`)
+}
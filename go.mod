@@ -1,9 +1,15 @@
 module github.com/aliakseiz/gocluster
 
-go 1.16
+go 1.18
 
 require (
 	github.com/electrious-go/kdbush v0.0.0-20180903131831-3bb354b8f7c8
 	github.com/rakyll/statik v0.1.7
 	github.com/stretchr/testify v1.6.1
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
@@ -1,7 +1,5 @@
 package cluster
 
-import "math"
-
 func round(val float64) int {
 	if val < 0 {
 		return int(val - 0.5)
@@ -10,8 +8,9 @@ func round(val float64) int {
 	return int(val + 0.5)
 }
 
-// digitsCount counts number of digits.
-// 123456 will return 6.
-func digitsCount(a int) int {
-	return int(math.Floor(math.Log10(math.Abs(float64(a))))) + 1
+// sqDist returns the squared distance between the points.
+func sqDist(ax, ay, bx, by float64) float64 {
+	dx, dy := ax-bx, ay-by
+
+	return dx*dx + dy*dy
 }
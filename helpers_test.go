@@ -31,6 +31,7 @@ type TestPoint struct {
 
 type properties struct {
 	Name       string
+	ScaleRank  int `json:"scalerank"`
 	PointCount int `json:"point_count"`
 }
 
@@ -132,3 +133,21 @@ func floatEquals(a, b float64) bool {
 
 	return false
 }
+
+type weightedPoint struct {
+	simplePoint
+	Weight float64
+}
+
+func (wp weightedPoint) GetWeight() float64 {
+	return wp.Weight
+}
+
+type prioritizedPoint struct {
+	simplePoint
+	Priority float64
+}
+
+func (pp prioritizedPoint) GetPriority() float64 {
+	return pp.Priority
+}
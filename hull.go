@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"sort"
+
+	"github.com/electrious-go/kdbush"
+)
+
+// GetClusterHull returns the convex hull of the original points of the cluster in counter-clockwise order,
+// e.g. to show the area, covered by the cluster, on hover.
+// Hull is calculated in world coordinates of the projection, so its edges are straight lines on the map.
+// The ring is not closed, the first vertex is not repeated at the end.
+// Hull of points at the same position or on a line has one or two vertices.
+// Hulls are calculated on the first request and cached until the cluster is updated.
+// Returns ErrClusterNotFound if there is no cluster with the ID.
+func (c *Cluster) GetClusterHull(clusterID int64) ([]GeoCoordinates, error) {
+	hull, err := c.hull(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	coordinates := make([]GeoCoordinates, len(hull))
+	for i, p := range hull {
+		coordinates[i] = c.projection.Unproject(p.X, p.Y)
+	}
+
+	return coordinates, nil
+}
+
+// hull returns the cached convex hull of the cluster in world coordinates, calculating it on the first call.
+func (c *Cluster) hull(clusterID int64) ([]kdbush.SimplePoint, error) {
+	c.hullsMu.Lock()
+	hull, ok := c.hulls[clusterID]
+	c.hullsMu.Unlock()
+
+	if ok {
+		return hull, nil
+	}
+
+	leaves, err := c.getLeaves(clusterID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	// leaves keep projected coordinates of the original points
+	points := make([]kdbush.SimplePoint, len(leaves))
+	for i, p := range leaves {
+		points[i].X, points[i].Y = p.X, p.Y
+	}
+
+	hull = convexHull(points)
+
+	c.hullsMu.Lock()
+	if c.hulls == nil {
+		c.hulls = make(map[int64][]kdbush.SimplePoint)
+	}
+
+	c.hulls[clusterID] = hull
+	c.hullsMu.Unlock()
+
+	return hull, nil
+}
+
+// convexHull returns the convex hull of the points in world coordinates with monotone chain algorithm,
+// in counter-clockwise order with north up. Points are reordered.
+func convexHull(points []kdbush.SimplePoint) []kdbush.SimplePoint {
+	// Y of world coordinates grows to the south, so points are sorted from the west and from the north
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+
+		return points[i].Y > points[j].Y
+	})
+	// cross is positive for counter-clockwise turn from o-a to o-b with north up
+	cross := func(o, a, b kdbush.SimplePoint) float64 {
+		return (b.X-o.X)*(a.Y-o.Y) - (a.X-o.X)*(b.Y-o.Y)
+	}
+
+	hull := make([]kdbush.SimplePoint, 0, 2*len(points))
+	// lower chain from the west to the east, then upper chain back
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+
+		hull = append(hull, p)
+	}
+
+	lower := len(hull) + 1
+
+	for i := len(points) - 2; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], points[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+
+		hull = append(hull, points[i])
+	}
+	// the last point is the first one
+	hull = hull[:len(hull)-1]
+
+	if len(hull) == 2 && hull[0] == hull[1] {
+		hull = hull[:1]
+	}
+
+	return hull
+}
@@ -0,0 +1,183 @@
+package cluster_test
+
+import (
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// northUp returns mercator coordinates with y axis to the north.
+func northUp(coordinates cluster.GeoCoordinates) (float64, float64) {
+	x, y := cluster.MercatorProjection(coordinates)
+
+	return x, -y
+}
+
+// turn is positive for counter-clockwise turn from a-b to a-c with north up.
+func turn(a, b, c cluster.GeoCoordinates) float64 {
+	ax, ay := northUp(a)
+	bx, by := northUp(b)
+	cx, cy := northUp(c)
+
+	return (bx-ax)*(cy-ay) - (by-ay)*(cx-ax)
+}
+
+func TestCluster_GetClusterHull(t *testing.T) {
+	points := importData("./testdata/places.json")
+	assert.NotEmptyf(t, points, "no points for clustering")
+
+	geoPoints := make([]cluster.GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	clusters := 0
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		for _, p := range c.AllClusters(z, -1) {
+			if !p.IsCluster(c) {
+				_, err = c.GetClusterHull(p.ID)
+				require.Equal(t, cluster.ErrClusterNotFound, err)
+
+				continue
+			}
+
+			clusters++
+
+			hull, err := c.GetClusterHull(p.ID)
+			require.NoError(t, err)
+			require.NotEmpty(t, hull)
+
+			cached, err := c.GetClusterHull(p.ID)
+			require.NoError(t, err)
+			assert.Equal(t, hull, cached)
+
+			leaves, err := c.GetLeaves(p.ID, -1, 0)
+			require.NoError(t, err)
+
+			if len(hull) < 3 {
+				continue
+			}
+
+			for i := range hull {
+				a, b, next := hull[i], hull[(i+1)%len(hull)], hull[(i+2)%len(hull)]
+				require.Greater(t, turn(a, b, next), 0.0, "hull of cluster %d isn't convex", p.ID)
+
+				for _, leaf := range leaves {
+					require.GreaterOrEqual(t, turn(a, b, *leaf.GetCoordinates()), -1e-12,
+						"leaf %d is out of hull of cluster %d", leaf.GetID(), p.ID)
+				}
+			}
+		}
+	}
+
+	assert.NotZero(t, clusters)
+}
+
+func TestCluster_GetClusterHull_Degenerate(t *testing.T) {
+	geoPoints := []cluster.GeoPoint{
+		simplePoint{ID: 1, Lon: 10, Lat: 20},
+		simplePoint{ID: 2, Lon: 10, Lat: 20},
+		simplePoint{ID: 3, Lon: 10, Lat: 20},
+		simplePoint{ID: 4, Lon: 50, Lat: -20},
+		simplePoint{ID: 5, Lon: 50.1, Lat: -20},
+		simplePoint{ID: 6, Lon: 50.2, Lat: -20},
+	}
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 3))
+	require.NoError(t, err)
+
+	clusters := c.AllClusters(0, -1)
+	require.Len(t, clusters, 2)
+
+	for _, p := range clusters {
+		require.True(t, p.IsCluster(c))
+
+		hull, err := c.GetClusterHull(p.ID)
+		require.NoError(t, err)
+
+		if p.X < 30 {
+			require.Len(t, hull, 1)
+			assert.InDelta(t, 10, hull[0].Lng, 1e-9)
+			assert.InDelta(t, 20, hull[0].Lat, 1e-9)
+		} else {
+			require.Len(t, hull, 2)
+			assert.InDelta(t, 50, hull[0].Lng, 1e-9)
+			assert.InDelta(t, 50.2, hull[1].Lng, 1e-9)
+		}
+	}
+}
+
+func TestCluster_GetClusterHull_Update(t *testing.T) {
+	geoPoints := []cluster.GeoPoint{
+		simplePoint{ID: 1, Lon: 10, Lat: 20},
+		simplePoint{ID: 2, Lon: 10.1, Lat: 20},
+		simplePoint{ID: 3, Lon: 10, Lat: 20.1},
+	}
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 3))
+	require.NoError(t, err)
+
+	clusters := c.AllClusters(0, -1)
+	require.Len(t, clusters, 1)
+
+	hull, err := c.GetClusterHull(clusters[0].ID)
+	require.NoError(t, err)
+	require.Len(t, hull, 3)
+
+	c.Insert(simplePoint{ID: 4, Lon: 10.1, Lat: 20.1})
+
+	clusters = c.AllClusters(0, -1)
+	require.Len(t, clusters, 1)
+
+	hull, err = c.GetClusterHull(clusters[0].ID)
+	require.NoError(t, err)
+	require.Len(t, hull, 4)
+}
+
+// vanishingPoint has coordinates only on the first call of GetCoordinates.
+type vanishingPoint struct {
+	simplePoint
+	calls *int
+}
+
+func (vp vanishingPoint) GetCoordinates() *cluster.GeoCoordinates {
+	*vp.calls++
+	if *vp.calls > 1 {
+		return nil
+	}
+
+	return vp.simplePoint.GetCoordinates()
+}
+
+func TestCluster_GetClusterHull_CoordinatesOnce(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+	vanishing := make([]cluster.GeoPoint, len(geoPoints))
+	calls := make([]int, len(geoPoints))
+
+	for i, p := range geoPoints {
+		vanishing[i] = vanishingPoint{p.(simplePoint), &calls[i]}
+	}
+
+	c, err := cluster.New(vanishing, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	for _, p := range c.AllClusters(0, -1) {
+		if !p.IsCluster(c) {
+			continue
+		}
+
+		hull, err := c.GetClusterHull(p.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, hull)
+	}
+	// coordinates are obtained once, when the cluster is created
+	for i := range calls {
+		assert.Equal(t, 1, calls[i], "point %d", i)
+	}
+}
@@ -0,0 +1,389 @@
+package cluster
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultMVTExtent is the default extent of Mapbox Vector Tile.
+	DefaultMVTExtent = 4096
+	// DefaultMVTLayerName is the default name of Mapbox Vector Tile layer with clusters.
+	DefaultMVTLayerName = "clusters"
+	// DefaultMVTHullsLayerName is the default name of Mapbox Vector Tile layer with convex hulls of clusters.
+	DefaultMVTHullsLayerName = "hulls"
+)
+
+// Mapbox Vector Tile v2 protobuf fields, see https://github.com/mapbox/vector-tile-spec/blob/master/2.1/vector_tile.proto
+const (
+	mvtTileLayers = 3
+
+	mvtLayerName     = 1
+	mvtLayerFeatures = 2
+	mvtLayerKeys     = 3
+	mvtLayerValues   = 4
+	mvtLayerExtent   = 5
+	mvtLayerVersion  = 15
+
+	mvtFeatureID       = 1
+	mvtFeatureTags     = 2
+	mvtFeatureType     = 3
+	mvtFeatureGeometry = 4
+
+	mvtValueString = 1
+	mvtValueFloat  = 2
+	mvtValueDouble = 3
+	mvtValueUint   = 5
+	mvtValueSint   = 6
+	mvtValueBool   = 7
+
+	mvtGeomTypePoint   = 1
+	mvtGeomTypePolygon = 3
+
+	mvtCommandMoveTo    = 1
+	mvtCommandLineTo    = 2
+	mvtCommandClosePath = 7
+)
+
+// protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// MVTOptions configures Mapbox Vector Tile encoding.
+type MVTOptions struct {
+	// LayerName is the name of the layer with clusters, DefaultMVTLayerName is used if empty
+	LayerName string
+	// Extent is the tile extent, DefaultMVTExtent is used if not positive
+	Extent int
+	// Properties converts Point.Properties, created with aggregator, into the feature properties.
+	// If not set, Point.Properties of map[string]interface{} type are used as is, any other type is ignored.
+	// Supported values are strings, booleans, integer and float numbers, other values are encoded as strings.
+	Properties func(properties interface{}) map[string]interface{}
+	// LeafProperties returns properties of the original point, used for features of single points
+	// instead of the aggregated properties.
+	LeafProperties func(p GeoPoint) map[string]interface{}
+	// Groups limits features to points and clusters of the groups, all points are encoded if empty, see WithGroupBy
+	Groups []string
+	// Hulls adds the layer of polygon features of convex hulls of the tile clusters, see GetClusterHull.
+	// Hull features have the cluster ID and "cluster_id" property.
+	// Clusters of points at the same position or on a line have no hull feature.
+	Hulls bool
+	// HullsLayerName is the name of the layer with hulls, DefaultMVTHullsLayerName is used if empty
+	HullsLayerName string
+}
+
+// GetTileMVT returns points of the tile with coordinates x and y and for zoom z,
+// encoded as a Mapbox Vector Tile v2 with a layer of point features and optional layer of cluster hulls.
+// Each point feature has "cluster" and "point_count" properties, clusters have "cluster_id" property as well,
+// grouped points have "group" property, followed by the aggregated properties.
+// Returns ErrInvalidTile if tile coordinates are out of range.
+func (c *Cluster) GetTileMVT(x, y, z int, opts MVTOptions) ([]byte, error) {
+	if !validTile(x, y, z) {
+		return nil, ErrInvalidTile
+	}
+
+	if opts.LayerName == "" {
+		opts.LayerName = DefaultMVTLayerName
+	}
+
+	if opts.Extent <= 0 {
+		opts.Extent = DefaultMVTExtent
+	}
+
+	if opts.HullsLayerName == "" {
+		opts.HullsLayerName = DefaultMVTHullsLayerName
+	}
+
+	layer := newMVTLayer(opts.LayerName, opts.Extent)
+	hulls := newMVTLayer(opts.HullsLayerName, opts.Extent)
+
+	c.eachTilePoint(x, y, z, opts.Groups, func(p *Point, tileX float64) {
+		px, py := tilePixel(p.X, p.Y, tileX, float64(y), z, opts.Extent)
+		layer.addPoint(uint64(p.ID), int64(px), int64(py), c.mvtProperties(*p, opts))
+
+		if opts.Hulls && p.IsCluster(c) {
+			c.addHull(hulls, p.ID, y, z, tileX, opts.Extent)
+		}
+	})
+
+	tile := appendBytesField(nil, mvtTileLayers, layer.encode())
+
+	if opts.Hulls {
+		tile = appendBytesField(tile, mvtTileLayers, hulls.encode())
+	}
+
+	return tile, nil
+}
+
+// addHull adds polygon feature of the cluster hull in pixel coordinates of the tile to the layer,
+// if the hull has at least three distinct vertices in the tile. Its ring is clockwise, as required for exterior rings.
+func (c *Cluster) addHull(layer *mvtLayer, clusterID int64, y, z int, tileX float64, extent int) {
+	hull, err := c.hull(clusterID)
+	if err != nil || len(hull) < 3 {
+		return
+	}
+
+	ring := make([][2]int64, 0, len(hull))
+
+	for _, v := range hull {
+		px, py := tilePixel(v.X, v.Y, tileX, float64(y), z, extent)
+		vertex := [2]int64{int64(px), int64(py)}
+
+		if len(ring) == 0 || ring[len(ring)-1] != vertex {
+			ring = append(ring, vertex)
+		}
+	}
+
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+
+	var area int64
+
+	for i, v := range ring {
+		next := ring[(i+1)%len(ring)]
+		area += v[0]*next[1] - next[0]*v[1]
+	}
+	// rounding to pixels could collapse the ring into a line
+	if len(ring) < 3 || area == 0 {
+		return
+	}
+	// surveyor's formula of the exterior ring is positive with y axis down
+	if area < 0 {
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+	}
+
+	layer.addPolygon(uint64(clusterID), ring, []mvtProperty{{"cluster_id", clusterID}})
+}
+
+// mvtProperties returns properties of the feature in encoding order.
+func (c *Cluster) mvtProperties(p Point, opts MVTOptions) []mvtProperty {
+	isCluster := p.IsCluster(c)
+	properties := []mvtProperty{
+		{"cluster", isCluster},
+	}
+
+	if isCluster {
+		properties = append(properties, mvtProperty{"cluster_id", p.ID})
+	}
+
+	properties = append(properties, mvtProperty{"point_count", p.NumPoints})
+
+	if p.Group != "" {
+		properties = append(properties, mvtProperty{"group", p.Group})
+	}
+
+	var aggregated map[string]interface{}
+
+	if !isCluster && opts.LeafProperties != nil {
+		aggregated = opts.LeafProperties(c.leaf(int(p.ID)))
+	} else if opts.Properties != nil {
+		aggregated = opts.Properties(p.Properties)
+	} else if m, ok := p.Properties.(map[string]interface{}); ok {
+		aggregated = m
+	}
+
+	keys := make([]string, 0, len(aggregated))
+	for key := range aggregated {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if aggregated[key] == nil {
+			continue
+		}
+
+		properties = append(properties, mvtProperty{key, aggregated[key]})
+	}
+
+	return properties
+}
+
+type mvtProperty struct {
+	key   string
+	value interface{}
+}
+
+// mvtLayer encodes features of a single layer, deduplicating keys and values.
+type mvtLayer struct {
+	name     string
+	extent   int
+	features []byte
+	keys     []string
+	values   [][]byte
+	keyIdx   map[string]uint64
+	valueIdx map[string]uint64
+}
+
+func newMVTLayer(name string, extent int) *mvtLayer {
+	return &mvtLayer{
+		name:     name,
+		extent:   extent,
+		keyIdx:   make(map[string]uint64),
+		valueIdx: make(map[string]uint64),
+	}
+}
+
+func (l *mvtLayer) addPoint(id uint64, x, y int64, properties []mvtProperty) {
+	geometry := appendVarint(nil, mvtCommandMoveTo|1<<3)
+	geometry = appendVarint(geometry, zigzag(x))
+	geometry = appendVarint(geometry, zigzag(y))
+
+	l.addFeature(id, mvtGeomTypePoint, geometry, properties)
+}
+
+// addPolygon adds polygon feature with a single ring, which is not closed.
+func (l *mvtLayer) addPolygon(id uint64, ring [][2]int64, properties []mvtProperty) {
+	geometry := appendVarint(nil, mvtCommandMoveTo|1<<3)
+	geometry = appendVarint(geometry, zigzag(ring[0][0]))
+	geometry = appendVarint(geometry, zigzag(ring[0][1]))
+	geometry = appendVarint(geometry, mvtCommandLineTo|uint64(len(ring)-1)<<3)
+
+	for i := 1; i < len(ring); i++ {
+		geometry = appendVarint(geometry, zigzag(ring[i][0]-ring[i-1][0]))
+		geometry = appendVarint(geometry, zigzag(ring[i][1]-ring[i-1][1]))
+	}
+
+	geometry = appendVarint(geometry, mvtCommandClosePath|1<<3)
+
+	l.addFeature(id, mvtGeomTypePolygon, geometry, properties)
+}
+
+func (l *mvtLayer) addFeature(id uint64, geomType uint64, geometry []byte, properties []mvtProperty) {
+	tags := make([]byte, 0, 4*len(properties))
+
+	for _, property := range properties {
+		tags = appendVarint(tags, l.key(property.key))
+		tags = appendVarint(tags, l.value(property.value))
+	}
+
+	var feature []byte
+	feature = appendVarintField(feature, mvtFeatureID, id)
+	feature = appendBytesField(feature, mvtFeatureTags, tags)
+	feature = appendVarintField(feature, mvtFeatureType, geomType)
+	feature = appendBytesField(feature, mvtFeatureGeometry, geometry)
+
+	l.features = appendBytesField(l.features, mvtLayerFeatures, feature)
+}
+
+func (l *mvtLayer) key(key string) uint64 {
+	idx, ok := l.keyIdx[key]
+	if !ok {
+		idx = uint64(len(l.keys))
+		l.keyIdx[key] = idx
+		l.keys = append(l.keys, key)
+	}
+
+	return idx
+}
+
+func (l *mvtLayer) value(value interface{}) uint64 {
+	encoded := encodeMVTValue(value)
+
+	idx, ok := l.valueIdx[string(encoded)]
+	if !ok {
+		idx = uint64(len(l.values))
+		l.valueIdx[string(encoded)] = idx
+		l.values = append(l.values, encoded)
+	}
+
+	return idx
+}
+
+func (l *mvtLayer) encode() []byte {
+	var layer []byte
+	layer = appendVarintField(layer, mvtLayerVersion, 2)
+	layer = appendBytesField(layer, mvtLayerName, []byte(l.name))
+	layer = append(layer, l.features...)
+
+	for _, key := range l.keys {
+		layer = appendBytesField(layer, mvtLayerKeys, []byte(key))
+	}
+
+	for _, value := range l.values {
+		layer = appendBytesField(layer, mvtLayerValues, value)
+	}
+
+	return appendVarintField(layer, mvtLayerExtent, uint64(l.extent))
+}
+
+// encodeMVTValue encodes the value as a Value message of vector tile.
+func encodeMVTValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		return appendBytesField(nil, mvtValueString, []byte(v))
+	case bool:
+		var b uint64
+		if v {
+			b = 1
+		}
+
+		return appendVarintField(nil, mvtValueBool, b)
+	case float32:
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, math.Float32bits(v))
+
+		return append(appendKey(nil, mvtValueFloat, wireFixed32), b...)
+	case float64:
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+
+		return append(appendKey(nil, mvtValueDouble, wireFixed64), b...)
+	case int:
+		return appendVarintField(nil, mvtValueSint, zigzag(int64(v)))
+	case int8:
+		return appendVarintField(nil, mvtValueSint, zigzag(int64(v)))
+	case int16:
+		return appendVarintField(nil, mvtValueSint, zigzag(int64(v)))
+	case int32:
+		return appendVarintField(nil, mvtValueSint, zigzag(int64(v)))
+	case int64:
+		return appendVarintField(nil, mvtValueSint, zigzag(v))
+	case uint:
+		return appendVarintField(nil, mvtValueUint, uint64(v))
+	case uint8:
+		return appendVarintField(nil, mvtValueUint, uint64(v))
+	case uint16:
+		return appendVarintField(nil, mvtValueUint, uint64(v))
+	case uint32:
+		return appendVarintField(nil, mvtValueUint, uint64(v))
+	case uint64:
+		return appendVarintField(nil, mvtValueUint, v)
+	default:
+		return appendBytesField(nil, mvtValueString, []byte(fmt.Sprint(v)))
+	}
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendKey(b []byte, field, wireType int) []byte {
+	return appendVarint(b, uint64(field<<3|wireType))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	return appendVarint(appendKey(b, field, wireVarint), v)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendVarint(appendKey(b, field, wireBytes), uint64(len(v)))
+
+	return append(b, v...)
+}
@@ -0,0 +1,329 @@
+package cluster_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mvtFeature struct {
+	ID   uint64
+	X, Y int64
+	// Ring is the ring of the polygon feature, X and Y are coordinates of the point feature
+	Ring       [][2]int64
+	Properties map[string]interface{}
+}
+
+type mvtLayer struct {
+	Version  uint64
+	Name     string
+	Extent   uint64
+	Features []mvtFeature
+}
+
+// protoFields decodes protobuf message into the list of fields, only wire types used by vector tiles are supported.
+func protoFields(t *testing.T, b []byte) (fields []int, values []interface{}) {
+	t.Helper()
+
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		require.Greater(t, n, 0)
+		b = b[n:]
+
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			require.Greater(t, n, 0)
+			b = b[n:]
+			values = append(values, v)
+		case 1:
+			values = append(values, binary.LittleEndian.Uint64(b))
+			b = b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			require.Greater(t, n, 0)
+			values = append(values, b[n:n+int(l)])
+			b = b[n+int(l):]
+		case 5:
+			values = append(values, binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+
+		fields = append(fields, int(key>>3))
+	}
+
+	return fields, values
+}
+
+func packedVarints(t *testing.T, b []byte) []uint64 {
+	t.Helper()
+
+	var result []uint64
+
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		require.Greater(t, n, 0)
+		b = b[n:]
+		result = append(result, v)
+	}
+
+	return result
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// decodeRing decodes geometry of the polygon with a single ring.
+func decodeRing(t *testing.T, geometry []uint64) [][2]int64 {
+	t.Helper()
+
+	require.GreaterOrEqual(t, len(geometry), 6)
+	require.Equal(t, uint64(9), geometry[0])
+
+	lineTo := geometry[3]
+	require.Equal(t, uint64(2), lineTo&7)
+
+	count := int(lineTo >> 3)
+	require.Len(t, geometry, 5+2*count)
+	require.Equal(t, uint64(15), geometry[len(geometry)-1])
+
+	ring := [][2]int64{{unzigzag(geometry[1]), unzigzag(geometry[2])}}
+	for i := 0; i < count; i++ {
+		last := ring[len(ring)-1]
+		ring = append(ring, [2]int64{
+			last[0] + unzigzag(geometry[4+2*i]),
+			last[1] + unzigzag(geometry[5+2*i]),
+		})
+	}
+
+	return ring
+}
+
+func decodeMVT(t *testing.T, tile []byte) []mvtLayer {
+	t.Helper()
+
+	var layers []mvtLayer
+
+	fields, values := protoFields(t, tile)
+	for i := range fields {
+		require.Equal(t, 3, fields[i])
+
+		var (
+			layer    mvtLayer
+			keys     []string
+			vals     []interface{}
+			features [][]byte
+		)
+
+		lf, lv := protoFields(t, values[i].([]byte))
+		for j := range lf {
+			switch lf[j] {
+			case 1:
+				layer.Name = string(lv[j].([]byte))
+			case 2:
+				features = append(features, lv[j].([]byte))
+			case 3:
+				keys = append(keys, string(lv[j].([]byte)))
+			case 4:
+				vf, vv := protoFields(t, lv[j].([]byte))
+				require.Len(t, vf, 1)
+
+				switch vf[0] {
+				case 1:
+					vals = append(vals, string(vv[0].([]byte)))
+				case 3:
+					vals = append(vals, math.Float64frombits(vv[0].(uint64)))
+				case 5:
+					vals = append(vals, vv[0].(uint64))
+				case 6:
+					vals = append(vals, unzigzag(vv[0].(uint64)))
+				case 7:
+					vals = append(vals, vv[0].(uint64) == 1)
+				}
+			case 5:
+				layer.Extent = lv[j].(uint64)
+			case 15:
+				layer.Version = lv[j].(uint64)
+			}
+		}
+
+		for _, f := range features {
+			feature := mvtFeature{Properties: make(map[string]interface{})}
+
+			var geomType uint64
+
+			ff, fv := protoFields(t, f)
+			for j := range ff {
+				switch ff[j] {
+				case 1:
+					feature.ID = fv[j].(uint64)
+				case 2:
+					tags := packedVarints(t, fv[j].([]byte))
+					for k := 0; k < len(tags); k += 2 {
+						feature.Properties[keys[tags[k]]] = vals[tags[k+1]]
+					}
+				case 3:
+					geomType = fv[j].(uint64)
+					require.Contains(t, []uint64{1, 3}, geomType)
+				case 4:
+					geometry := packedVarints(t, fv[j].([]byte))
+					if geomType == 3 {
+						feature.Ring = decodeRing(t, geometry)
+
+						continue
+					}
+
+					require.Len(t, geometry, 3)
+					require.Equal(t, uint64(9), geometry[0])
+					feature.X = unzigzag(geometry[1])
+					feature.Y = unzigzag(geometry[2])
+				}
+			}
+
+			layer.Features = append(layer.Features, feature)
+		}
+
+		layers = append(layers, layer)
+	}
+
+	return layers
+}
+
+func TestCluster_GetTileMVT(t *testing.T) {
+	points := importData("./testdata/places.json")
+	assert.NotEmptyf(t, points, "no points for clustering")
+
+	geoPoints := make([]cluster.GeoPoint, len(points))
+
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	c, err := cluster.New(geoPoints,
+		cluster.WithinZoom(0, 3),
+		cluster.WithPointSize(60),
+		cluster.WithTileSize(256),
+		cluster.WithAggregator(
+			func(p cluster.GeoPoint) interface{} {
+				return map[string]interface{}{"rank": p.(*TestPoint).Properties.ScaleRank}
+			},
+			func(accumulated, props interface{}) interface{} {
+				a, b := accumulated.(map[string]interface{}), props.(map[string]interface{})
+
+				return map[string]interface{}{"rank": a["rank"].(int) + b["rank"].(int)}
+			}))
+	require.NoError(t, err)
+
+	tile, err := c.GetTileMVT(0, 0, 0, cluster.MVTOptions{})
+	require.NoError(t, err)
+
+	layers := decodeMVT(t, tile)
+	require.Len(t, layers, 1)
+	assert.Equal(t, "clusters", layers[0].Name)
+	assert.Equal(t, uint64(2), layers[0].Version)
+	assert.Equal(t, uint64(4096), layers[0].Extent)
+
+	expected := c.GetTile(0, 0, 0)
+	require.Equal(t, len(expected), len(layers[0].Features))
+
+	for i, f := range layers[0].Features {
+		p := expected[i]
+		assert.Equal(t, uint64(p.ID), f.ID)
+		// tile size is 256, so coordinates are multiplied by 16 with rounding error
+		assert.InDelta(t, p.X*16, f.X, 16)
+		assert.InDelta(t, p.Y*16, f.Y, 16)
+		assert.Equal(t, p.IsCluster(c), f.Properties["cluster"])
+		assert.Equal(t, int64(p.NumPoints), f.Properties["point_count"])
+		assert.Equal(t, int64(p.Properties.(map[string]interface{})["rank"].(int)), f.Properties["rank"])
+
+		if p.IsCluster(c) {
+			assert.Equal(t, int64(p.ID), f.Properties["cluster_id"])
+		} else {
+			assert.NotContains(t, f.Properties, "cluster_id")
+		}
+	}
+
+	tile, err = c.GetTileMVT(1, 0, 1, cluster.MVTOptions{
+		LayerName: "places",
+		Extent:    512,
+		Properties: func(properties interface{}) map[string]interface{} {
+			return map[string]interface{}{"label": "x", "ratio": 0.5}
+		},
+	})
+	require.NoError(t, err)
+
+	layers = decodeMVT(t, tile)
+	require.Len(t, layers, 1)
+	assert.Equal(t, "places", layers[0].Name)
+	assert.Equal(t, uint64(512), layers[0].Extent)
+	require.NotEmpty(t, layers[0].Features)
+	assert.Equal(t, "x", layers[0].Features[0].Properties["label"])
+	assert.Equal(t, 0.5, layers[0].Features[0].Properties["ratio"])
+	assert.NotContains(t, layers[0].Features[0].Properties, "rank")
+
+	_, err = c.GetTileMVT(2, 0, 1, cluster.MVTOptions{})
+	assert.Equal(t, cluster.ErrInvalidTile, err)
+}
+
+func TestCluster_GetTileMVT_Hulls(t *testing.T) {
+	points := importData("./testdata/places.json")
+	assert.NotEmptyf(t, points, "no points for clustering")
+
+	geoPoints := make([]cluster.GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 3))
+	require.NoError(t, err)
+
+	tile, err := c.GetTileMVT(0, 0, 1, cluster.MVTOptions{Hulls: true})
+	require.NoError(t, err)
+
+	layers := decodeMVT(t, tile)
+	require.Len(t, layers, 2)
+	assert.Equal(t, cluster.DefaultMVTLayerName, layers[0].Name)
+	assert.Equal(t, cluster.DefaultMVTHullsLayerName, layers[1].Name)
+	require.NotEmpty(t, layers[1].Features)
+
+	clusters := make(map[uint64]bool)
+	for _, f := range layers[0].Features {
+		if f.Properties["cluster"] == true {
+			clusters[f.ID] = true
+		}
+	}
+
+	for _, f := range layers[1].Features {
+		assert.True(t, clusters[f.ID], "hull of unknown cluster %d", f.ID)
+		assert.Equal(t, map[string]interface{}{"cluster_id": int64(f.ID)}, f.Properties)
+		require.GreaterOrEqual(t, len(f.Ring), 3)
+
+		hull, err := c.GetClusterHull(int64(f.ID))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(f.Ring), len(hull))
+
+		// exterior ring has positive area in tile coordinates
+		var area int64
+
+		for i, v := range f.Ring {
+			next := f.Ring[(i+1)%len(f.Ring)]
+			area += v[0]*next[1] - next[0]*v[1]
+		}
+
+		assert.Greater(t, area, int64(0))
+	}
+
+	tile, err = c.GetTileMVT(0, 0, 1, cluster.MVTOptions{Hulls: true, HullsLayerName: "areas"})
+	require.NoError(t, err)
+
+	layers = decodeMVT(t, tile)
+	require.Len(t, layers, 2)
+	assert.Equal(t, "areas", layers[1].Name)
+}
@@ -1,5 +1,7 @@
 package cluster
 
+import "runtime"
+
 // Option allows modifying cluster properties or cluster itself.
 type Option func(*Cluster) error
 
@@ -46,3 +48,231 @@ func WithNodeSize(size int) Option {
 		return nil
 	}
 }
+
+// WithParallelism will set number of goroutines used to create clusters in New.
+// Points of each zoom level are split into vertical strips, clustered concurrently,
+// and clusters along strip borders are reconciled, so the result is identical to the serial build.
+// KD-trees are built concurrently with clustering. Zoom levels with few points are clustered serially.
+// ReduceFunc of WithAggregator must be safe for concurrent use, if parallelism is above 1.
+// If n is not positive, runtime.GOMAXPROCS(0) is used.
+func WithParallelism(n int) Option {
+	return func(c *Cluster) error {
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+
+		c.parallelism = n
+
+		return nil
+	}
+}
+
+// WithMinPoints will set minimum number of points to form a cluster, same as minPoints option of mapbox/supercluster.
+// Groups of fewer points remain at the zoom level as individual points and clusters.
+// Values below 2 have the same effect as default value 2.
+func WithMinPoints(n int) Option {
+	return func(c *Cluster) error {
+		c.MinPoints = n
+		return nil
+	}
+}
+
+// WithStableIDs will derive cluster IDs from the clustered original points instead of the clustering order,
+// so clusters with the same points at the same zoom level keep their IDs when the cluster is rebuilt
+// from the updated points, e.g. to keep expanded or selected clusters of clients.
+// ID is a hash of the GeoPoint IDs of the cluster members and the zoom level,
+// points of TypedCluster are identified by their positions.
+// On a hash collision with another cluster of the zoom level, the next free ID is taken in the clustering order,
+// so colliding clusters could swap IDs after rebuild. Collisions are rare, 46 bits of the hash are used.
+// Zoom level is still encoded in the IDs, see DecodeClusterID,
+// but index isn't the order of creation of the cluster anymore.
+func WithStableIDs() Option {
+	return func(c *Cluster) error {
+		c.stableIDs = true
+		return nil
+	}
+}
+
+// GroupFunc returns the group of the point, e.g. its category.
+type GroupFunc func(p GeoPoint) string
+
+// WithGroupBy will set function to split points into groups, which are clustered independently,
+// so points of different groups are never merged into the same cluster.
+// Points and clusters are tagged with their group in Point.Group, and queries could be limited to some groups.
+// Groups of points of TypedCluster are obtained from TypedPoint values, see WithTypedGroupBy to avoid boxing them.
+// Groups are stored in snapshots, but the function should be provided to Load as well to group inserted points.
+func WithGroupBy(fn GroupFunc) Option {
+	return func(c *Cluster) error {
+		if fn == nil {
+			return ErrInvalidGroupFunc
+		}
+
+		c.groupFn = fn
+		c.typedGroupFn = nil
+
+		return nil
+	}
+}
+
+// WithTypedGroupBy is WithGroupBy for TypedCluster, fn obtains groups of T values without boxing.
+// Cluster, created of other points type, returns ErrInvalidGroupFunc.
+func WithTypedGroupBy[T any](fn func(p T) string) Option {
+	return func(c *Cluster) error {
+		if fn == nil {
+			return ErrInvalidGroupFunc
+		}
+
+		c.groupFn = nil
+		c.typedGroupFn = fn
+
+		return nil
+	}
+}
+
+// WithWeightedSeeds will let points of higher weight seed clusters first at each zoom level,
+// so heavy points attract their lighter neighbours, see WeightedGeoPoint.
+// Points of the same weight seed clusters in their order.
+func WithWeightedSeeds() Option {
+	return func(c *Cluster) error {
+		c.weightedSeeds = true
+		return nil
+	}
+}
+
+// WithProjection will set the projection of the points into the world coordinates, they are clustered in,
+// WebMercator by default. Points are clustered and tiles are cut in the world coordinates, see Projection,
+// while areas of queries and coordinates of the returned points and clusters are not projected.
+// Projection is stored in snapshots, custom projection can't be stored and should be provided to Load as well.
+// Returns ErrInvalidProjection if the projection is nil.
+func WithProjection(projection Projection) Option {
+	return func(c *Cluster) error {
+		if projection == nil {
+			return ErrInvalidProjection
+		}
+
+		c.projection = projection
+
+		return nil
+	}
+}
+
+// WithAlgorithm will set the algorithm to merge points into clusters at each zoom level, Greedy by default.
+// Clusters of all algorithms form the same hierarchy, so all queries work the same way.
+// Incremental updates of other algorithms than Greedy rebuild all zoom levels from the updated points,
+// so IDs of unaffected clusters are kept with WithStableIDs option only.
+// Algorithm is stored in snapshots, custom algorithm can't be stored and should be provided to Load as well.
+// Returns ErrInvalidAlgorithm if the algorithm is nil.
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(c *Cluster) error {
+		if algorithm == nil {
+			return ErrInvalidAlgorithm
+		}
+
+		c.algorithm = algorithm
+
+		return nil
+	}
+}
+
+// CentroidStrategy defines the position of the cluster, see WithCentroidStrategy.
+type CentroidStrategy int
+
+const (
+	// CentroidWeightedMean places the cluster in the weighted mean of its points, see WeightedGeoPoint.
+	CentroidWeightedMean CentroidStrategy = iota
+	// CentroidMedoid places the cluster at its point or child cluster, nearest to the weighted mean,
+	// so the cluster is always at the position of one of its original points.
+	CentroidMedoid
+	// CentroidHighestPriority places the cluster at its point of the highest priority, see PrioritizedGeoPoint.
+	// The first point of the cluster is taken among points of the same priority.
+	CentroidHighestPriority
+)
+
+// WithCentroidStrategy will set the position of clusters, CentroidWeightedMean by default.
+// Clusters of the zoom level are clustered at their positions at the zoom level above.
+// Priorities of points of TypedCluster are obtained from TypedPoint values.
+// Returns ErrInvalidCentroidStrategy if the strategy is unknown.
+func WithCentroidStrategy(strategy CentroidStrategy) Option {
+	return func(c *Cluster) error {
+		if !strategy.valid() {
+			return ErrInvalidCentroidStrategy
+		}
+
+		c.centroid = strategy
+
+		return nil
+	}
+}
+
+func (s CentroidStrategy) valid() bool {
+	return s >= CentroidWeightedMean && s <= CentroidHighestPriority
+}
+
+// RadiusFunc returns the clustering radius in pixels at the zoom level.
+type RadiusFunc func(zoom int) float64
+
+// WithRadiusFunc will set clustering radius for each zoom level, instead of PointSize radius for all of them,
+// e.g. to cluster heavily at country level and lightly at street level.
+// The same radius is used to find cluster children, expansion zoom and tile buffers.
+// Per-zoom table could be provided as a function as well:
+//
+//	radii := map[int]float64{0: 120, 1: 120, 2: 100, 3: 80}
+//	cluster.WithRadiusFunc(func(zoom int) float64 {
+//		if r, ok := radii[zoom]; ok {
+//			return r
+//		}
+//		return 40
+//	})
+func WithRadiusFunc(fn RadiusFunc) Option {
+	return func(c *Cluster) error {
+		if fn == nil {
+			return ErrInvalidRadiusFunc
+		}
+
+		c.radiusFn = fn
+
+		return nil
+	}
+}
+
+// MapFunc returns properties of a single point, which are later aggregated into cluster properties.
+type MapFunc func(p GeoPoint) interface{}
+
+// ReduceFunc merges properties of a cluster member into the accumulated cluster properties.
+// It must not modify its arguments, as they are shared with points of other zoom levels,
+// and should return the merged value instead.
+type ReduceFunc func(accumulated, props interface{}) interface{}
+
+// WithAggregator will set map and reduce functions to aggregate point properties into clusters,
+// same as map/reduce options of mapbox/supercluster.
+// Properties of each point are obtained with mapFn and stored in Point.Properties,
+// clusters properties are calculated with reduceFn, starting from the properties of the first cluster member.
+func WithAggregator(mapFn MapFunc, reduceFn ReduceFunc) Option {
+	return func(c *Cluster) error {
+		if mapFn == nil || reduceFn == nil {
+			return ErrInvalidAggregator
+		}
+
+		c.mapFn = mapFn
+		c.typedMapFn = nil
+		c.reduceFn = reduceFn
+
+		return nil
+	}
+}
+
+// WithTypedAggregator is WithAggregator for TypedCluster, mapFn obtains properties of T values without boxing.
+// Cluster, created of other points type, returns ErrInvalidAggregator.
+func WithTypedAggregator[T any](mapFn func(p T) interface{}, reduceFn ReduceFunc) Option {
+	return func(c *Cluster) error {
+		if mapFn == nil || reduceFn == nil {
+			return ErrInvalidAggregator
+		}
+
+		c.mapFn = nil
+		c.typedMapFn = mapFn
+		c.reduceFn = reduceFn
+
+		return nil
+	}
+}
@@ -0,0 +1,285 @@
+package cluster
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/electrious-go/kdbush"
+)
+
+const (
+	// parallelMinPoints is the minimum number of points of a zoom level, clustered in parallel
+	parallelMinPoints = 4096
+	// stripSamples is the number of sampled points per strip to find strip borders
+	stripSamples = 64
+)
+
+// strip is a vertical strip of the zoom level, clustered concurrently with other strips.
+// Strip members are own points of the strip and points of the neighbour strips within the band along its borders,
+// which could merge own points into their clusters.
+type strip struct {
+	// members are positions of the points in the zoom level, in the order they seed clusters
+	members []int
+	own     []bool
+	// foreignSeeds marks members of the neighbour strips, which start a cluster or remain unclustered
+	foreignSeeds []bool
+	tree         *kdbush.KDBush
+}
+
+// buildParallel creates indexes of all zoom levels, same as the serial build in New.
+// KD-tree of the zoom level is built concurrently with clustering of the zoom level above,
+// which uses KD-trees of the strips until the clusters are created.
+func (c *Cluster) buildParallel(clusters []*Point) {
+	for z := c.MaxZoom; z >= c.MinZoom; z-- {
+		treeBuilt := make(chan struct{})
+
+		go func(level int, points []*Point) {
+			c.Indexes[level] = kdbush.NewBush(clustersToPoints(points), c.NodeSize)
+			close(treeBuilt)
+		}(z+1-c.MinZoom, clusters)
+
+		clusters = c.clusterizeParallel(clusters, z, treeBuilt)
+	}
+
+	c.Indexes[0] = kdbush.NewBush(clustersToPoints(clusters), c.NodeSize)
+}
+
+// clusterizeParallel produces the same clusters as clusterize, using strips of the zoom level.
+//
+// Serial clustering visits points in order, each not yet merged point starts a cluster (seed),
+// merging all not yet merged points within the radius. So the point is a seed, if there is no seed before it
+// within the radius, otherwise it's merged into the first such seed.
+// Strips find seeds of their own points concurrently, taking seeds of the neighbour strips within the border band
+// into account. Seeds along the borders are unknown in the beginning, so strips are reclustered
+// until seeds of all bands remain unchanged. Finally, clusters are created from the seeds,
+// using KD-tree of the zoom level to keep the order of merged points the same as in serial clustering.
+func (c *Cluster) clusterizeParallel(points []*Point, zoom int, treeBuilt <-chan struct{}) []*Point {
+	r := c.worldRadius(zoom)
+
+	order := c.seedOrder(points)
+
+	strips := c.partition(points, order, r)
+	if len(strips) < 2 {
+		<-treeBuilt
+
+		return c.clusterize(points, zoom)
+	}
+
+	seeds := make([]bool, len(points))
+	// owners are positions of the seeds, points are merged into
+	owners := make([]int, len(points))
+
+	dirty := strips
+
+	for len(dirty) > 0 {
+		parallelize(len(dirty), c.parallelism, func(i int) {
+			dirty[i].findSeeds(points, r, c.NodeSize, seeds, owners)
+		})
+
+		dirty = dirty[:0:0]
+
+		for _, s := range strips {
+			changed := false
+
+			for i, p := range s.members {
+				if !s.own[i] && s.foreignSeeds[i] != seeds[p] {
+					s.foreignSeeds[i] = seeds[p]
+					changed = true
+				}
+			}
+
+			if changed {
+				dirty = append(dirty, s)
+			}
+		}
+	}
+
+	var seedPositions []int
+
+	for i := range points {
+		if order != nil {
+			i = order[i]
+		}
+
+		if seeds[i] {
+			seedPositions = append(seedPositions, i)
+		}
+	}
+
+	<-treeBuilt
+
+	tree := c.Indexes[zoom+1-c.MinZoom]
+	neighbours := make([][]*Point, len(seedPositions))
+	// positions of the seeds at the zoom level, as too small groups remain at the zoom level point by point
+	positions := make([]int, len(seedPositions)+1)
+
+	parallelize(len(seedPositions), c.parallelism, func(i int) {
+		seed := seedPositions[i]
+
+		for _, j := range tree.Within(points[seed], r) {
+			if j != seed && owners[j] == seed {
+				neighbours[i] = append(neighbours[i], points[j])
+			}
+		}
+	})
+
+	// IDs and slots of the new clusters are assigned in the order of the seeds, as IDs depend on it
+	ids := make([]int64, len(seedPositions))
+	slots := make([]int, len(seedPositions))
+
+	for i, seed := range seedPositions {
+		positions[i+1] = positions[i] + 1
+		if c.formsCluster(points[seed], neighbours[i]) {
+			ids[i], slots[i] = c.reserveCluster(zoom, points[seed], neighbours[i])
+		} else {
+			positions[i+1] += len(neighbours[i])
+		}
+	}
+
+	result := make([]*Point, positions[len(seedPositions)])
+
+	parallelize(len(seedPositions), c.parallelism, func(i int) {
+		p, index := points[seedPositions[i]], positions[i]
+
+		if c.formsCluster(p, neighbours[i]) {
+			result[index] = c.setCluster(zoom, slots[i], ids[i], p, neighbours[i])
+
+			return
+		}
+
+		result[index] = p
+		copy(result[index+1:], neighbours[i])
+	})
+
+	return result
+}
+
+// partition splits points into vertical strips with similar number of points, at least 4 radiuses wide.
+// Members of the strips are added in the seed order, see seedOrder.
+// Returns nil if the zoom level should be clustered serially.
+func (c *Cluster) partition(points []*Point, order []int, r float64) []*strip {
+	if c.parallelism < 2 || len(points) < parallelMinPoints {
+		return nil
+	}
+
+	step := len(points) / (c.parallelism * stripSamples)
+	if step < 1 {
+		step = 1
+	}
+
+	samples := make([]float64, 0, len(points)/step+1)
+	for i := 0; i < len(points); i += step {
+		samples = append(samples, points[i].X)
+	}
+
+	sort.Float64s(samples)
+
+	var borders []float64
+
+	last, maxX := samples[0], samples[len(samples)-1]
+
+	for k := 1; k < c.parallelism; k++ {
+		border := samples[k*len(samples)/c.parallelism]
+		if border-last >= 4*r && maxX-border >= 4*r {
+			borders = append(borders, border)
+			last = border
+		}
+	}
+
+	if len(borders) == 0 {
+		return nil
+	}
+
+	strips := make([]*strip, len(borders)+1)
+	for i := range strips {
+		strips[i] = &strip{}
+	}
+	// band is wider than the radius to be safe against rounding of the distances
+	band := 2 * r
+
+	for i := range points {
+		if order != nil {
+			i = order[i]
+		}
+
+		p := points[i]
+		s := sort.Search(len(borders), func(j int) bool { return borders[j] > p.X })
+		strips[s].add(i, true)
+
+		if s > 0 && p.X-borders[s-1] <= band {
+			strips[s-1].add(i, false)
+		}
+
+		if s < len(borders) && borders[s]-p.X <= band {
+			strips[s+1].add(i, false)
+		}
+	}
+
+	return strips
+}
+
+func (s *strip) add(position int, own bool) {
+	s.members = append(s.members, position)
+	s.own = append(s.own, own)
+	s.foreignSeeds = append(s.foreignSeeds, false)
+}
+
+// findSeeds clusters own points of the strip in the same order as serial clustering,
+// marking seeds and owners of the merged points.
+func (s *strip) findSeeds(points []*Point, r float64, nodeSize int, seeds []bool, owners []int) {
+	if s.tree == nil {
+		members := make([]kdbush.Point, len(s.members))
+		for i, p := range s.members {
+			members[i] = points[p]
+		}
+
+		s.tree = kdbush.NewBush(members, nodeSize)
+	}
+
+	visited := make([]bool, len(s.members))
+
+	for i, position := range s.members {
+		if s.own[i] {
+			if visited[i] {
+				continue
+			}
+
+			visited[i] = true
+			seeds[position] = true
+			owners[position] = position
+		} else if !s.foreignSeeds[i] {
+			continue
+		}
+		// not yet visited own points are always after the seed
+		for _, j := range s.tree.Within(points[position], r) {
+			if s.own[j] && !visited[j] && points[s.members[j]].Group == points[position].Group {
+				visited[j] = true
+				seeds[s.members[j]] = false
+				owners[s.members[j]] = position
+			}
+		}
+	}
+}
+
+// parallelize calls fn for all indexes from 0 to n-1, using up to workers goroutines.
+func parallelize(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := w; i < n; i += workers {
+				fn(i)
+			}
+		}(w)
+	}
+
+	wg.Wait()
+}
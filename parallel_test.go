@@ -0,0 +1,175 @@
+package cluster_test
+
+import (
+	"math/rand"
+	"runtime"
+	"sort"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomPoints returns points, uniformly distributed or concentrated around a few centers.
+func randomPoints(n int, centers int, seed int64) []cluster.GeoPoint {
+	random := rand.New(rand.NewSource(seed))
+	points := make([]cluster.GeoPoint, n)
+
+	for i := range points {
+		if centers == 0 {
+			points[i] = simplePoint{int64(i), random.Float64()*360 - 180, random.Float64()*170 - 85}
+
+			continue
+		}
+
+		center := float64(random.Intn(centers))
+		points[i] = simplePoint{int64(i), center*20 - 100 + random.NormFloat64()*3, center*7 - 30 + random.NormFloat64()*2}
+	}
+
+	return points
+}
+
+// assertSameIndexes verifies that all zoom levels of the clusters have the same points in the same order.
+// Points, removed by incremental updates, are nil in the zoom levels until their KD-trees are rebuilt, so they are skipped.
+func assertSameIndexes(t *testing.T, expected, actual *cluster.Cluster) {
+	t.Helper()
+
+	require.Len(t, actual.Indexes, len(expected.Indexes))
+
+	for i := range expected.Indexes {
+		expectedPoints, actualPoints := levelPoints(expected, i), levelPoints(actual, i)
+		require.Len(t, actualPoints, len(expectedPoints), "level %d", i)
+
+		for j := range expectedPoints {
+			require.Equal(t, *expectedPoints[j], *actualPoints[j], "level %d, point %d", i, j)
+		}
+	}
+}
+
+// levelPoints returns points of the zoom level of the cluster without removed ones.
+func levelPoints(c *cluster.Cluster, level int) []*cluster.Point {
+	var points []*cluster.Point
+
+	for _, kp := range c.Indexes[level].Points {
+		if p := kp.(*cluster.Point); p != nil {
+			points = append(points, p)
+		}
+	}
+
+	return points
+}
+
+func TestWithParallelism(t *testing.T) {
+	sorted := randomPoints(10000, 0, 3)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetCoordinates().Lng < sorted[j].GetCoordinates().Lng
+	})
+
+	datasets := map[string][]cluster.GeoPoint{
+		"uniform":   randomPoints(10000, 0, 1),
+		"clustered": randomPoints(10000, 10, 2),
+		"sorted":    sorted,
+	}
+
+	for name, points := range datasets {
+		t.Run(name, func(t *testing.T) {
+			serial, err := cluster.New(points, cluster.WithinZoom(0, 16))
+			require.NoError(t, err)
+
+			for _, parallelism := range []int{2, 5, 16} {
+				parallel, err := cluster.New(points, cluster.WithinZoom(0, 16), cluster.WithParallelism(parallelism))
+				require.NoError(t, err)
+
+				assertSameIndexes(t, serial, parallel)
+			}
+
+			serial, err = cluster.New(points, cluster.WithinZoom(0, 16), cluster.WithMinPoints(5))
+			require.NoError(t, err)
+
+			parallel, err := cluster.New(points, cluster.WithinZoom(0, 16), cluster.WithMinPoints(5), cluster.WithParallelism(4))
+			require.NoError(t, err)
+
+			assertSameIndexes(t, serial, parallel)
+		})
+	}
+}
+
+func TestWithParallelism_Aggregator(t *testing.T) {
+	points := randomPoints(10000, 5, 4)
+	opts := []cluster.Option{
+		cluster.WithinZoom(2, 14),
+		cluster.WithAggregator(
+			func(p cluster.GeoPoint) interface{} {
+				return p.GetID()
+			},
+			func(accumulated, props interface{}) interface{} {
+				return accumulated.(int64) + props.(int64)
+			}),
+	}
+
+	serial, err := cluster.New(points, opts...)
+	require.NoError(t, err)
+
+	parallel, err := cluster.New(points, append(opts, cluster.WithParallelism(0))...)
+	require.NoError(t, err)
+
+	assertSameIndexes(t, serial, parallel)
+	assertHierarchy(t, parallel, len(points))
+
+	expansion := serial.AllClusters(5, -1)
+	for _, p := range expansion {
+		if p.IsCluster(serial) {
+			assert.Equal(t, serial.GetClusterExpansionZoom(p.ID), parallel.GetClusterExpansionZoom(p.ID))
+		}
+	}
+}
+
+func Benchmark_New(b *testing.B) {
+	points := randomPoints(100000, 20, 5)
+
+	for i := 0; i < b.N; i++ {
+		_, _ = cluster.New(points)
+	}
+}
+
+func Benchmark_NewParallel(b *testing.B) {
+	points := randomPoints(100000, 20, 5)
+
+	for i := 0; i < b.N; i++ {
+		_, _ = cluster.New(points, cluster.WithParallelism(0))
+	}
+}
+
+// retainedPerPoint limits the heap in bytes, retained by the cluster of uniformly distributed points
+// over the default zoom range, per point. Most of it is taken by KD-trees of all zoom levels.
+const retainedPerPoint = 900
+
+// Benchmark_NewMemory reports heap, retained by the cluster of 1M points.
+// Keeping children in per-level tables brings it back from 1669 MB to 852 MB, close to 813 MB without them.
+func Benchmark_NewMemory(b *testing.B) {
+	points := randomPoints(1000000, 0, 5)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		c, err := cluster.New(points)
+		require.NoError(b, err)
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(c)
+
+		retained := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+		b.ReportMetric(float64(retained)/1e6, "MB-retained")
+
+		if retained > retainedPerPoint*int64(len(points)) {
+			b.Errorf("cluster retains %d bytes per point, more than %d", retained/int64(len(points)), retainedPerPoint)
+		}
+	}
+}
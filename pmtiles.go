@@ -0,0 +1,434 @@
+package cluster
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// PMTiles v3 format constants, see https://github.com/protomaps/PMTiles/blob/main/spec/v3/spec.md
+const (
+	pmtilesMagic         = "PMTiles"
+	pmtilesVersion       = 3
+	pmtilesHeaderSize    = 127
+	pmtilesRootSize      = 16384
+	pmtilesLeafEntries   = 4096
+	pmtilesCompressGzip  = 2
+	pmtilesTileTypeMVT   = 1
+	pmtilesMaxZoom       = 30
+	pmtilesCoordinateMul = 1e7
+)
+
+var (
+	ErrInvalidZoomRange        = errors.New("invalid zoom range")
+	ErrNonGeographicProjection = errors.New("projection is not geographic")
+)
+
+// PMTilesOptions configures PMTiles archive writing.
+type PMTilesOptions struct {
+	// MVT configures encoding of the tiles
+	MVT MVTOptions
+	// Name of the tileset in the archive metadata
+	Name string
+	// Metadata is added to the archive metadata, overriding the generated values
+	Metadata map[string]interface{}
+	// Workers is the number of tiles encoded in parallel, runtime.GOMAXPROCS(0) is used if not positive
+	Workers int
+}
+
+// pmtilesEntry is an entry of PMTiles directory.
+// Entry with zero RunLength points to the leaf directory.
+type pmtilesEntry struct {
+	TileID    uint64
+	Offset    uint64
+	Length    uint64
+	RunLength uint64
+}
+
+// WritePMTiles writes all non-empty tiles of the zoom range, encoded as Mapbox Vector Tiles, into w
+// as a single PMTiles v3 archive, which could be range-served from object storage or opened by MapLibre.
+// Tiles are gzip-compressed and ordered by Hilbert tile IDs, identical tiles are stored once.
+// Tile data is kept in memory until the archive is written, as the header precedes it.
+// Returns ErrInvalidZoomRange if zoom range is out of [0, 30].
+// Returns ErrNonGeographicProjection if the world of the projection doesn't wrap around the antimeridian,
+// e.g. Planar, as the archive bounds are longitudes and latitudes, see WrappingProjection.
+func (c *Cluster) WritePMTiles(w io.Writer, minZoom, maxZoom int, opts PMTilesOptions) error {
+	if minZoom < 0 || maxZoom > pmtilesMaxZoom || minZoom > maxZoom {
+		return ErrInvalidZoomRange
+	}
+
+	if !c.wraps() {
+		return ErrNonGeographicProjection
+	}
+
+	if opts.MVT.LayerName == "" {
+		opts.MVT.LayerName = DefaultMVTLayerName
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		data      bytes.Buffer
+		entries   []pmtilesEntry
+		addressed uint64
+	)
+	// offsets and lengths of the written tiles by their content hash
+	contents := make(map[[sha256.Size]byte]pmtilesEntry)
+
+	for z := minZoom; z <= maxZoom; z++ {
+		tiles := c.NonEmptyTiles(z)
+
+		ids := make([]uint64, len(tiles))
+		for i, t := range tiles {
+			ids[i] = pmtilesTileID(t.Z, t.X, t.Y)
+		}
+
+		sortTilesByID(tiles, ids)
+
+		encoded, err := c.encodePMTiles(tiles, opts)
+		if err != nil {
+			return err
+		}
+
+		for i, tile := range encoded {
+			addressed++
+			hash := sha256.Sum256(tile)
+
+			entry, ok := contents[hash]
+			if !ok {
+				entry = pmtilesEntry{Offset: uint64(data.Len()), Length: uint64(len(tile))}
+				contents[hash] = entry
+				data.Write(tile)
+			}
+
+			if n := len(entries); n > 0 {
+				last := &entries[n-1]
+				if last.Offset == entry.Offset && last.TileID+last.RunLength == ids[i] {
+					last.RunLength++
+
+					continue
+				}
+			}
+
+			entries = append(entries, pmtilesEntry{TileID: ids[i], Offset: entry.Offset, Length: entry.Length, RunLength: 1})
+		}
+	}
+
+	root, leaves, err := buildPMTilesDirectories(entries)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := c.pmtilesMetadata(minZoom, maxZoom, opts)
+	if err != nil {
+		return err
+	}
+
+	header := pmtilesHeader{
+		rootOffset:     pmtilesHeaderSize,
+		rootLength:     uint64(len(root)),
+		metadataLength: uint64(len(metadata)),
+		leavesLength:   uint64(len(leaves)),
+		dataLength:     uint64(data.Len()),
+		addressed:      addressed,
+		entries:        uint64(len(entries)),
+		contents:       uint64(len(contents)),
+		minZoom:        minZoom,
+		maxZoom:        maxZoom,
+	}
+	header.metadataOffset = header.rootOffset + header.rootLength
+	header.leavesOffset = header.metadataOffset + header.metadataLength
+	header.dataOffset = header.leavesOffset + header.leavesLength
+	header.bounds = c.pmtilesBounds()
+
+	for _, b := range [][]byte{header.encode(), root, metadata, leaves, data.Bytes()} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodePMTiles encodes tiles as gzip-compressed Mapbox Vector Tiles in parallel, keeping the order of the tiles.
+func (c *Cluster) encodePMTiles(tiles []TileCoordinates, opts PMTilesOptions) ([][]byte, error) {
+	encoded := make([][]byte, len(tiles))
+	errs := make([]error, opts.Workers)
+
+	var wg sync.WaitGroup
+
+	for worker := 0; worker < opts.Workers; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			for i := worker; i < len(tiles); i += opts.Workers {
+				tile, err := c.GetTileMVT(tiles[i].X, tiles[i].Y, tiles[i].Z, opts.MVT)
+				if err == nil {
+					encoded[i], err = gzipBytes(tile)
+				}
+
+				if err != nil {
+					errs[worker] = err
+
+					return
+				}
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return encoded, nil
+}
+
+// pmtilesMetadata returns gzip-compressed JSON metadata of the archive.
+func (c *Cluster) pmtilesMetadata(minZoom, maxZoom int, opts PMTilesOptions) ([]byte, error) {
+	metadata := map[string]interface{}{
+		"name":   opts.Name,
+		"format": "pbf",
+		"type":   "overlay",
+		"vector_layers": []map[string]interface{}{{
+			"id":      opts.MVT.LayerName,
+			"minzoom": minZoom,
+			"maxzoom": maxZoom,
+			"fields": map[string]string{
+				"cluster":     "Boolean",
+				"cluster_id":  "Number",
+				"point_count": "Number",
+			},
+		}},
+	}
+
+	for key, value := range opts.Metadata {
+		metadata[key] = value
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return gzipBytes(encoded)
+}
+
+// pmtilesBounds returns bounds of all points as min lng, min lat, max lng, max lat,
+// limited to valid coordinates, as they are written as int32 values.
+func (c *Cluster) pmtilesBounds() [4]float64 {
+	bounds := c.GetBounds()
+	lng := func(v float64) float64 { return math.Max(-180, math.Min(180, v)) }
+	lat := func(v float64) float64 { return math.Max(-90, math.Min(90, v)) }
+
+	return [4]float64{lng(bounds.MinLng), lat(bounds.MinLat), lng(bounds.MaxLng), lat(bounds.MaxLat)}
+}
+
+// buildPMTilesDirectories returns compressed root directory and leaf directories.
+// Entries are split into leaf directories if root directory doesn't fit into the first 16 KiB of the archive.
+func buildPMTilesDirectories(entries []pmtilesEntry) ([]byte, []byte, error) {
+	root, err := encodePMTilesDirectory(entries)
+	if err != nil || len(root) <= pmtilesRootSize-pmtilesHeaderSize {
+		return root, nil, err
+	}
+
+	for leafSize := pmtilesLeafEntries; ; leafSize *= 2 {
+		var (
+			leaves      []byte
+			rootEntries []pmtilesEntry
+		)
+
+		for start := 0; start < len(entries); start += leafSize {
+			end := start + leafSize
+			if end > len(entries) {
+				end = len(entries)
+			}
+
+			leaf, err := encodePMTilesDirectory(entries[start:end])
+			if err != nil {
+				return nil, nil, err
+			}
+
+			rootEntries = append(rootEntries, pmtilesEntry{
+				TileID: entries[start].TileID,
+				Offset: uint64(len(leaves)),
+				Length: uint64(len(leaf)),
+			})
+			leaves = append(leaves, leaf...)
+		}
+
+		root, err = encodePMTilesDirectory(rootEntries)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(root) <= pmtilesRootSize-pmtilesHeaderSize {
+			return root, leaves, nil
+		}
+	}
+}
+
+// encodePMTilesDirectory returns gzip-compressed directory with column-oriented entries:
+// delta-encoded tile IDs, run lengths, lengths and offsets, where offset of the entry,
+// directly following the previous one, is encoded as 0.
+func encodePMTilesDirectory(entries []pmtilesEntry) ([]byte, error) {
+	b := appendVarint(nil, uint64(len(entries)))
+
+	var lastID uint64
+
+	for _, e := range entries {
+		b = appendVarint(b, e.TileID-lastID)
+		lastID = e.TileID
+	}
+
+	for _, e := range entries {
+		b = appendVarint(b, e.RunLength)
+	}
+
+	for _, e := range entries {
+		b = appendVarint(b, e.Length)
+	}
+
+	for i, e := range entries {
+		if i > 0 && e.Offset == entries[i-1].Offset+entries[i-1].Length {
+			b = appendVarint(b, 0)
+		} else {
+			b = appendVarint(b, e.Offset+1)
+		}
+	}
+
+	return gzipBytes(b)
+}
+
+// pmtilesHeader is the fixed size header of PMTiles archive.
+type pmtilesHeader struct {
+	rootOffset, rootLength         uint64
+	metadataOffset, metadataLength uint64
+	leavesOffset, leavesLength     uint64
+	dataOffset, dataLength         uint64
+	addressed, entries, contents   uint64
+	minZoom, maxZoom               int
+	// bounds are min lng, min lat, max lng, max lat
+	bounds [4]float64
+}
+
+func (h pmtilesHeader) encode() []byte {
+	b := make([]byte, pmtilesHeaderSize)
+	copy(b, pmtilesMagic)
+	b[7] = pmtilesVersion
+
+	for i, v := range []uint64{
+		h.rootOffset, h.rootLength, h.metadataOffset, h.metadataLength,
+		h.leavesOffset, h.leavesLength, h.dataOffset, h.dataLength,
+		h.addressed, h.entries, h.contents,
+	} {
+		binary.LittleEndian.PutUint64(b[8+8*i:], v)
+	}
+	// tile data is ordered by tile IDs
+	b[96] = 1
+	b[97] = pmtilesCompressGzip
+	b[98] = pmtilesCompressGzip
+	b[99] = pmtilesTileTypeMVT
+	b[100] = byte(h.minZoom)
+	b[101] = byte(h.maxZoom)
+
+	for i, v := range h.bounds {
+		binary.LittleEndian.PutUint32(b[102+4*i:], uint32(int32(math.Round(v*pmtilesCoordinateMul))))
+	}
+
+	b[118] = byte(h.minZoom)
+	binary.LittleEndian.PutUint32(b[119:], uint32(int32(math.Round((h.bounds[0]+h.bounds[2])/2*pmtilesCoordinateMul))))
+	binary.LittleEndian.PutUint32(b[123:], uint32(int32(math.Round((h.bounds[1]+h.bounds[3])/2*pmtilesCoordinateMul))))
+
+	return b
+}
+
+// pmtilesTileID returns PMTiles tile ID: number of tiles of all lower zoom levels
+// plus position of the tile on the Hilbert curve of its zoom level.
+func pmtilesTileID(z, x, y int) uint64 {
+	id := (uint64(1)<<(2*uint(z)) - 1) / 3
+	n := 1 << uint(z)
+
+	for s := n / 2; s > 0; s /= 2 {
+		var rx, ry int
+
+		if x&s > 0 {
+			rx = 1
+		}
+
+		if y&s > 0 {
+			ry = 1
+		}
+
+		id += uint64(s) * uint64(s) * uint64((3*rx)^ry)
+		// rotate the quadrant
+		if ry == 0 {
+			if rx == 1 {
+				x = n - 1 - x
+				y = n - 1 - y
+			}
+
+			x, y = y, x
+		}
+	}
+
+	return id
+}
+
+// sortTilesByID sorts tiles and their IDs by IDs.
+func sortTilesByID(tiles []TileCoordinates, ids []uint64) {
+	sort.Sort(tilesByID{tiles: tiles, ids: ids})
+}
+
+type tilesByID struct {
+	tiles []TileCoordinates
+	ids   []uint64
+}
+
+func (t tilesByID) Len() int           { return len(t.ids) }
+func (t tilesByID) Less(i, j int) bool { return t.ids[i] < t.ids[j] }
+func (t tilesByID) Swap(i, j int) {
+	t.ids[i], t.ids[j] = t.ids[j], t.ids[i]
+	t.tiles[i], t.tiles[j] = t.tiles[j], t.tiles[i]
+}
+
+// gzipWriters are reused, as allocation of gzip.Writer is much more expensive than compression of a tile.
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	gz := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(gz)
+
+	gz.Reset(&buf)
+
+	if _, err := gz.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,256 @@
+package cluster_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"sort"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pmtilesEntry struct {
+	TileID, Offset, Length, RunLength uint64
+}
+
+// pmtilesArchive is a minimal PMTiles v3 reader.
+type pmtilesArchive struct {
+	t           *testing.T
+	data        []byte
+	header      []uint64
+	directories map[uint64][]pmtilesEntry
+}
+
+func readPMTiles(t *testing.T, data []byte) *pmtilesArchive {
+	t.Helper()
+
+	require.Greater(t, len(data), 127)
+	require.Equal(t, "PMTiles", string(data[:7]))
+	require.Equal(t, byte(3), data[7])
+
+	header := make([]uint64, 11)
+	for i := range header {
+		header[i] = binary.LittleEndian.Uint64(data[8+8*i:])
+	}
+
+	return &pmtilesArchive{t: t, data: data, header: header, directories: make(map[uint64][]pmtilesEntry)}
+}
+
+func (a *pmtilesArchive) section(offset, length uint64) []byte {
+	a.t.Helper()
+
+	gz, err := gzip.NewReader(bytes.NewReader(a.data[offset : offset+length]))
+	require.NoError(a.t, err)
+
+	b, err := io.ReadAll(gz)
+	require.NoError(a.t, err)
+
+	return b
+}
+
+func (a *pmtilesArchive) directory(offset, length uint64) []pmtilesEntry {
+	a.t.Helper()
+
+	if entries, ok := a.directories[offset]; ok {
+		return entries
+	}
+
+	b := a.section(offset, length)
+	values := packedVarints(a.t, b)
+	n := int(values[0])
+	require.Len(a.t, values, 1+4*n)
+
+	entries := make([]pmtilesEntry, n)
+
+	var id uint64
+
+	for i := range entries {
+		id += values[1+i]
+		entries[i].TileID = id
+		entries[i].RunLength = values[1+n+i]
+		entries[i].Length = values[1+2*n+i]
+
+		if offset := values[1+3*n+i]; offset == 0 && i > 0 {
+			entries[i].Offset = entries[i-1].Offset + entries[i-1].Length
+		} else {
+			entries[i].Offset = offset - 1
+		}
+	}
+
+	a.directories[offset] = entries
+
+	return entries
+}
+
+// tile returns decompressed tile or nil if the archive doesn't contain it.
+func (a *pmtilesArchive) tile(z, x, y int) []byte {
+	a.t.Helper()
+
+	id := hilbertTileID(z, x, y)
+	entries := a.directory(a.header[0], a.header[1])
+
+	for depth := 0; depth < 4; depth++ {
+		i := sort.Search(len(entries), func(i int) bool { return entries[i].TileID > id })
+		if i == 0 {
+			return nil
+		}
+
+		found := entries[i-1]
+
+		if found.RunLength == 0 {
+			entries = a.directory(a.header[4]+found.Offset, found.Length)
+
+			continue
+		}
+
+		if id >= found.TileID+found.RunLength {
+			return nil
+		}
+
+		return a.section(a.header[6]+found.Offset, found.Length)
+	}
+
+	a.t.Fatal("too deep directories")
+
+	return nil
+}
+
+// hilbertTileID is the tile ID implementation from PMTiles specification.
+func hilbertTileID(z, x, y int) uint64 {
+	id := (uint64(1)<<(2*uint(z)) - 1) / 3
+
+	for a := z - 1; a >= 0; a-- {
+		s := uint32(1) << uint(a)
+		rx, ry := s&uint32(x), s&uint32(y)
+		id += uint64((3*rx)^ry) << uint(a)
+
+		if ry == 0 {
+			if rx != 0 {
+				x, y = int(s-1-uint32(x)), int(s-1-uint32(y))
+			}
+
+			x, y = y, x
+		}
+	}
+
+	return id
+}
+
+func TestHilbertTileID(t *testing.T) {
+	assert.Equal(t, uint64(0), hilbertTileID(0, 0, 0))
+	assert.Equal(t, uint64(1), hilbertTileID(1, 0, 0))
+	assert.Equal(t, uint64(2), hilbertTileID(1, 0, 1))
+	assert.Equal(t, uint64(3), hilbertTileID(1, 1, 1))
+	assert.Equal(t, uint64(4), hilbertTileID(1, 1, 0))
+	assert.Equal(t, uint64(5), hilbertTileID(2, 0, 0))
+	assert.Equal(t, uint64(19078479), hilbertTileID(12, 3423, 1763))
+}
+
+func assertPMTiles(t *testing.T, c *cluster.Cluster, data []byte, minZoom, maxZoom int) *pmtilesArchive {
+	t.Helper()
+
+	archive := readPMTiles(t, data)
+	total := 0
+	contents := make(map[string]bool)
+
+	for z := minZoom; z <= maxZoom; z++ {
+		tiles := c.NonEmptyTiles(z)
+		total += len(tiles)
+
+		for _, tile := range tiles {
+			expected, err := c.GetTileMVT(tile.X, tile.Y, tile.Z, cluster.MVTOptions{})
+			require.NoError(t, err)
+			require.Equal(t, expected, archive.tile(tile.Z, tile.X, tile.Y), "tile %v", tile)
+
+			contents[string(expected)] = true
+		}
+	}
+
+	assert.Equal(t, uint64(total), archive.header[8], "addressed tiles")
+	assert.Equal(t, uint64(len(contents)), archive.header[10], "tile contents")
+	assert.Nil(t, archive.tile(maxZoom+1, 0, 0))
+
+	return archive
+}
+
+func TestCluster_WritePMTiles(t *testing.T) {
+	c, err := cluster.New(importSimplePoints("./testdata/places.json"), cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, c.WritePMTiles(&buf, 0, 6, cluster.PMTilesOptions{Name: "places", Workers: 3}))
+
+	data := buf.Bytes()
+	archive := assertPMTiles(t, c, data, 0, 6)
+
+	// tiles of the empty region are not written
+	assert.Nil(t, archive.tile(6, 0, 0))
+	// no leaf directories for small pyramid
+	assert.Equal(t, uint64(0), archive.header[5])
+	// clustered, gzip compression, MVT tiles, zoom range
+	assert.Equal(t, []byte{1, 2, 2, 1, 0, 6}, data[96:102])
+
+	var metadata map[string]interface{}
+	require.NoError(t, json.Unmarshal(archive.section(archive.header[2], archive.header[3]), &metadata))
+	assert.Equal(t, "places", metadata["name"])
+	assert.Equal(t, "pbf", metadata["format"])
+
+	// the archive is deterministic
+	var again bytes.Buffer
+	require.NoError(t, c.WritePMTiles(&again, 0, 6, cluster.PMTilesOptions{Name: "places"}))
+	assert.Equal(t, data, again.Bytes())
+}
+
+func TestCluster_WritePMTilesLeafDirectories(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	points := make([]cluster.GeoPoint, 20000)
+
+	for i := range points {
+		points[i] = simplePoint{int64(i), random.Float64()*360 - 180, random.Float64()*170 - 85}
+	}
+
+	c, err := cluster.New(points, cluster.WithinZoom(0, 10))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, c.WritePMTiles(&buf, 0, 8, cluster.PMTilesOptions{}))
+
+	archive := assertPMTiles(t, c, buf.Bytes(), 0, 8)
+	assert.Greater(t, archive.header[5], uint64(0), "leaf directories")
+	assert.LessOrEqual(t, archive.header[1], uint64(16384-127), "root directory")
+}
+
+func TestCluster_WritePMTilesInvalidZoom(t *testing.T) {
+	c, err := cluster.New(importSimplePoints("./testdata/places.json"))
+	require.NoError(t, err)
+
+	assert.Equal(t, cluster.ErrInvalidZoomRange, c.WritePMTiles(io.Discard, 3, 2, cluster.PMTilesOptions{}))
+	assert.Equal(t, cluster.ErrInvalidZoomRange, c.WritePMTiles(io.Discard, -1, 2, cluster.PMTilesOptions{}))
+	assert.Equal(t, cluster.ErrInvalidZoomRange, c.WritePMTiles(io.Discard, 0, 31, cluster.PMTilesOptions{}))
+}
+
+func TestCluster_WritePMTilesProjection(t *testing.T) {
+	planar, err := cluster.New(importSimplePoints("./testdata/places.json"), cluster.WithProjection(cluster.Planar{}))
+	require.NoError(t, err)
+	assert.Equal(t, cluster.ErrNonGeographicProjection, planar.WritePMTiles(io.Discard, 0, 2, cluster.PMTilesOptions{}))
+
+	// bounds of the empty world are limited to valid latitudes
+	empty, err := cluster.New(nil, cluster.WithProjection(cluster.Equirectangular{}))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, empty.WritePMTiles(&buf, 0, 2, cluster.PMTilesOptions{}))
+
+	bounds := make([]int32, 4)
+	for i := range bounds {
+		bounds[i] = int32(binary.LittleEndian.Uint32(buf.Bytes()[102+4*i:]))
+	}
+
+	assert.Equal(t, []int32{-180e7, -90e7, 180e7, 90e7}, bounds)
+}
@@ -1,22 +1,44 @@
 package cluster
 
 import (
+	"math"
+
 	"github.com/electrious-go/kdbush"
 )
 
+const (
+	// clusterFlag is set in cluster IDs, IDs of the original points are below it
+	clusterFlag = int64(1) << 52
+	// clusterZoomShift is the position of the zoom level in the cluster ID
+	clusterZoomShift = 47
+	clusterZoomMask  = 1<<(52-clusterZoomShift) - 1
+	// filteredFlag is set in IDs of the clusters of GetClustersFiltered, which are not part of the hierarchy
+	filteredFlag     = int64(1) << 46
+	clusterIndexMask = filteredFlag - 1
+)
+
 // Point struct that implements clustered points
 // could have only one point or set of points.
 type Point struct {
-	X, Y      float64
-	zoom      int
-	ID        int // Index for pint, Id for cluster
+	X, Y     float64
+	parentID int64
+	// hash is the sum of hashes of IDs of the original points, clustered into the point, see WithStableIDs
+	hash uint64
+	// ID is the position of the original point in the points slice, or the cluster ID, see DecodeClusterID
+	ID        int64
 	NumPoints int
-	Included  []int64
+	// Weight is the total weight of the original points of the cluster, see WeightedGeoPoint
+	Weight float64
+	// Group is the group of the original points of the cluster, see WithGroupBy
+	Group string
+	// Properties keeps aggregated properties of the cluster, or mapped properties of a single point.
+	// Nil unless the Cluster is created WithAggregator.
+	Properties interface{}
 }
 
 // GetID to be compatible with interface.
 func (cp *Point) GetID() int64 {
-	return int64(cp.ID)
+	return cp.ID
 }
 
 // Coordinates to be compatible with interface.
@@ -32,9 +54,92 @@ func (cp *Point) GetCoordinates() *GeoCoordinates {
 	}
 }
 
+// Included returns IDs of all original points of the cluster, or ID of the original point.
+// IDs are not stored in the cluster, they are collected from the leaves of the cluster on each call.
+func (cp *Point) Included(c *Cluster) []int64 {
+	if !cp.IsCluster(c) {
+		return []int64{c.leafID(int(cp.ID))}
+	}
+
+	leaves, err := c.getLeaves(cp.ID, 0, 0)
+	if err != nil {
+		return nil
+	}
+
+	included := make([]int64, len(leaves))
+	for i, p := range leaves {
+		included[i] = c.leafID(int(p.ID))
+	}
+
+	return included
+}
+
 // IsCluster tells you if this point is cluster or rather regular point.
+// Cluster IDs don't depend on the Cluster, so c is not used.
 func (cp *Point) IsCluster(c *Cluster) bool {
-	return cp.ID >= c.clusterIdxSeed
+	return cp.ID >= clusterFlag
+}
+
+// DecodeClusterID returns the zoom level the cluster is created at and index of the cluster among
+// all clusters, created at that zoom level, in the order of creation.
+// For IDs of the original points, isCluster is false, index is the position of the point in the points slice and zoom is InfinityZoomLevel.
+//
+// IDs fit into 53 bits, so they are exactly represented by JavaScript numbers, e.g. in GeoJSON and MVT properties:
+//
+//	bit 52      set for clusters, so IDs of clusters and original points never collide
+//	bits 47-51  zoom level of the cluster
+//	bit 46      set for clusters of GetClustersFiltered, which are not part of the clusters hierarchy
+//	bits 0-45   index of the cluster at the zoom level, or position of the original point (bits 0-51)
+//
+// Zoom level is absolute, so IDs remain the same regardless of MinZoom.
+func DecodeClusterID(id int64) (zoom, index int, isCluster bool) {
+	if id < clusterFlag {
+		return InfinityZoomLevel, int(id), false
+	}
+
+	return int(id>>clusterZoomShift) & clusterZoomMask, int(id & clusterIndexMask), true
+}
+
+// clusterID returns ID of the cluster with the index among clusters of the zoom level.
+func clusterID(zoom, index int) int64 {
+	return clusterFlag | int64(zoom)<<clusterZoomShift | int64(index)
+}
+
+// stableClusterID returns ID of the cluster created at the zoom level, derived from the hash of its contents.
+// Probe is incremented on collisions with IDs of other clusters of the zoom level.
+func stableClusterID(zoom int, hash uint64, probe int) int64 {
+	return clusterFlag | int64(zoom)<<clusterZoomShift | int64(mix(hash)+uint64(probe))&clusterIndexMask
+}
+
+// leafHash returns hash of the original point ID, hashes of the cluster members are summed,
+// so the cluster hash doesn't depend on the order of the members.
+func leafHash(id int64) uint64 {
+	return mix(uint64(id))
+}
+
+// mix is the splitmix64 finalizer, spreading the bits of the value over the whole range.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
+
+// clusterZoom returns the zoom level the cluster is created at, or InfinityZoomLevel for no cluster (zero ID).
+func clusterZoom(id int64) int {
+	if id < clusterFlag {
+		return InfinityZoomLevel
+	}
+
+	return int(id>>clusterZoomShift) & clusterZoomMask
+}
+
+// Bounds is the rectangle, containing all original points of the cluster.
+type Bounds struct {
+	MinLng, MinLat, MaxLng, MaxLat float64
 }
 
 // GeoCoordinates represent position in the Earth.
@@ -50,26 +155,88 @@ type GeoPoint interface {
 	GetCoordinates() *GeoCoordinates
 }
 
-// translate geopoints to Points with projection coordinates.
-func translateGeoPointsToPoints(points []GeoPoint) []*Point {
+// WeightedGeoPoint is GeoPoint with a weight, e.g. capacity of the site.
+// Clusters are positioned in the weighted center of their points and keep the total weight in Point.Weight.
+// Weight of other points is 1, negative weights are treated as 0.
+type WeightedGeoPoint interface {
+	GeoPoint
+	GetWeight() float64
+}
+
+// weighted is implemented by the points, having a weight, e.g. WeightedGeoPoint.
+type weighted interface {
+	GetWeight() float64
+}
+
+// weightOf returns weight of the value, implementing GetWeight method, or 1.
+func weightOf(v interface{}) float64 {
+	weighted, ok := v.(weighted)
+	if !ok {
+		return 1
+	}
+
+	return math.Max(0, weighted.GetWeight())
+}
+
+// PrioritizedGeoPoint is GeoPoint with a priority, e.g. population of the city.
+// Clusters are positioned at their point of the highest priority with CentroidHighestPriority strategy.
+// Priority of other points is 0.
+type PrioritizedGeoPoint interface {
+	GeoPoint
+	GetPriority() float64
+}
+
+// prioritized is implemented by the points, having a priority, e.g. PrioritizedGeoPoint.
+type prioritized interface {
+	GetPriority() float64
+}
+
+// priorityOf returns priority of the value, implementing GetPriority method, or 0.
+func priorityOf(v interface{}) float64 {
+	prioritized, ok := v.(prioritized)
+	if !ok {
+		return 0
+	}
+
+	return prioritized.GetPriority()
+}
+
+// translatePoints creates Points with projection coordinates of the points, skipping points without coordinates.
+// ID of the created Point is the position of the point, starting from offset,
+// and Properties are obtained with properties function, if it's not nil.
+func translatePoints[T any](points []T, offset int, coordinates CoordinatesFunc[T], projection Projection,
+	properties func(i int) interface{},
+) []*Point {
 	result := make([]*Point, 0, len(points))
 	for i, p := range points {
-		geoPoint := p.GetCoordinates()
-		if geoPoint == nil { // Skip points without coordinates
+		lng, lat, ok := coordinates(p)
+		if !ok { // Skip points without coordinates
 			continue
 		}
 
 		cp := Point{}
-		cp.zoom = InfinityZoomLevel
-		cp.X, cp.Y = MercatorProjection(*geoPoint) // nil check is above
+		cp.X, cp.Y = projection.Project(GeoCoordinates{Lng: lng, Lat: lat})
 		result = append(result, &cp)
 		cp.NumPoints = 1
-		cp.ID = i
-		cp.Included = []int64{p.GetID()}
+		cp.ID = int64(offset + i)
+
+		if properties != nil {
+			cp.Properties = properties(offset + i)
+		}
 	}
 	return result
 }
 
+// geoPointCoordinates is CoordinatesFunc of GeoPoint.
+func geoPointCoordinates(p GeoPoint) (lng, lat float64, ok bool) {
+	geoPoint := p.GetCoordinates()
+	if geoPoint == nil {
+		return 0, 0, false
+	}
+
+	return geoPoint.Lng, geoPoint.Lat, true
+}
+
 func clustersToPoints(points []*Point) []kdbush.Point {
 	result := make([]kdbush.Point, len(points))
 	for i, v := range points {
@@ -0,0 +1,98 @@
+package cluster
+
+import "math"
+
+// Projection converts coordinates of the points into the world coordinates, the points are clustered in,
+// and back, see WithProjection.
+// World coordinates of the visible area are from 0 to 1, X grows to the east and Y to the south.
+// The world is a single tile at zoom 0, and tile x, y at zoom z covers x/2^z to (x+1)/2^z
+// and y/2^z to (y+1)/2^z of it. Clustering radius is in pixels of the tiles, so it's the same in both directions.
+type Projection interface {
+	// Project returns world coordinates of the coordinates.
+	Project(coordinates GeoCoordinates) (x, y float64)
+	// Unproject returns coordinates of the world coordinates.
+	Unproject(x, y float64) GeoCoordinates
+}
+
+// WrappingProjection is implemented by projections, which define whether the world wraps around the antimeridian.
+// Worlds of projections, not implementing it, wrap, so query areas, crossing the antimeridian, are split,
+// and the edge tiles get points of the opposite edge in their buffers.
+type WrappingProjection interface {
+	Projection
+	// Wraps returns true if the world wraps around the antimeridian.
+	Wraps() bool
+}
+
+// WebMercator is the spherical Web Mercator projection EPSG:3857 of web maps, the default projection.
+// Latitude is limited to about ±85.05°, so the world is square.
+type WebMercator struct{}
+
+// Project implements Projection.
+func (WebMercator) Project(coordinates GeoCoordinates) (float64, float64) {
+	return MercatorProjection(coordinates)
+}
+
+// Unproject implements Projection.
+func (WebMercator) Unproject(x, y float64) GeoCoordinates {
+	return ReverseMercatorProjection(x, y)
+}
+
+// Equirectangular is the equirectangular (Plate Carrée) projection of EPSG:4326 longitudes and latitudes.
+// Degrees of longitude and latitude have the same size, so the world takes the northern half of the square
+// and Y is up to 0.5. Tiles of zoom z+1 match tiles of zoom z of EPSG:4326 tile grids with two tiles at zoom 0,
+// e.g. WorldCRS84Quad.
+type Equirectangular struct{}
+
+// Project implements Projection.
+func (Equirectangular) Project(coordinates GeoCoordinates) (float64, float64) {
+	lat := math.Max(-90, math.Min(90, coordinates.Lat))
+
+	return coordinates.Lng/360 + 0.5, (90 - lat) / 360
+}
+
+// Unproject implements Projection.
+func (Equirectangular) Unproject(x, y float64) GeoCoordinates {
+	return GeoCoordinates{Lng: (x - 0.5) * 360, Lat: 90 - y*360}
+}
+
+// Planar uses Lng and Lat of the points as X and Y coordinates of the plane, divided by Extent,
+// e.g. pixels of the floorplan image, where Y grows downwards.
+// Plane doesn't wrap around the antimeridian, and query areas are not limited to valid longitudes and latitudes.
+type Planar struct {
+	// Extent is the size of the plane, which is mapped to the world at zoom 0, 1 if not positive
+	Extent float64
+}
+
+// Project implements Projection.
+func (p Planar) Project(coordinates GeoCoordinates) (float64, float64) {
+	extent := p.extent()
+
+	return coordinates.Lng / extent, coordinates.Lat / extent
+}
+
+// Unproject implements Projection.
+func (p Planar) Unproject(x, y float64) GeoCoordinates {
+	extent := p.extent()
+
+	return GeoCoordinates{Lng: x * extent, Lat: y * extent}
+}
+
+// Wraps implements WrappingProjection, plane doesn't wrap.
+func (Planar) Wraps() bool {
+	return false
+}
+
+func (p Planar) extent() float64 {
+	if p.Extent <= 0 {
+		return 1
+	}
+
+	return p.Extent
+}
+
+// wraps checks if the world of the cluster projection wraps around the antimeridian, see WrappingProjection.
+func (c *Cluster) wraps() bool {
+	wrapping, ok := c.projection.(WrappingProjection)
+
+	return !ok || wrapping.Wraps()
+}
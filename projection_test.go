@@ -0,0 +1,199 @@
+package cluster_test
+
+import (
+	"bytes"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjections(t *testing.T) {
+	for _, tt := range []struct {
+		projection  cluster.Projection
+		coordinates cluster.GeoCoordinates
+		x, y        float64
+	}{
+		{cluster.WebMercator{}, cluster.GeoCoordinates{Lng: 0, Lat: 0}, 0.5, 0.5},
+		{cluster.Equirectangular{}, cluster.GeoCoordinates{Lng: -180, Lat: 90}, 0, 0},
+		{cluster.Equirectangular{}, cluster.GeoCoordinates{Lng: 90, Lat: -45}, 0.75, 0.375},
+		{cluster.Planar{}, cluster.GeoCoordinates{Lng: 0.25, Lat: 0.5}, 0.25, 0.5},
+		{cluster.Planar{Extent: 1000}, cluster.GeoCoordinates{Lng: 250, Lat: 800}, 0.25, 0.8},
+	} {
+		x, y := tt.projection.Project(tt.coordinates)
+		assert.InDelta(t, tt.x, x, 1e-12, "%T", tt.projection)
+		assert.InDelta(t, tt.y, y, 1e-12, "%T", tt.projection)
+
+		coordinates := tt.projection.Unproject(x, y)
+		assert.InDelta(t, tt.coordinates.Lng, coordinates.Lng, 1e-9, "%T", tt.projection)
+		assert.InDelta(t, tt.coordinates.Lat, coordinates.Lat, 1e-9, "%T", tt.projection)
+	}
+}
+
+func TestWithProjection(t *testing.T) {
+	_, err := cluster.New(nil, cluster.WithProjection(nil))
+	assert.Equal(t, cluster.ErrInvalidProjection, err)
+
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16), cluster.WithProjection(cluster.Equirectangular{}))
+	require.NoError(t, err)
+	assertHierarchy(t, c, len(geoPoints))
+	assertBounds(t, c)
+
+	mercator, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+	// high latitudes are not stretched, so points there are merged at the same zoom level
+	assert.NotEqual(t, len(mercator.AllClusters(3, -1)), len(c.AllClusters(3, -1)))
+
+	world := []cluster.GeoPoint{&cluster.Point{X: -180, Y: 90}, &cluster.Point{X: 180, Y: -90}}
+
+	clusters, err := c.GetClusters(world[0], world[1], 3, -1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, c.AllClusters(3, -1), clusters)
+
+	for _, p := range clusters {
+		if !p.IsCluster(c) {
+			coordinates := geoPoints[p.ID].GetCoordinates()
+			assert.InDelta(t, coordinates.Lng, p.X, 1e-9)
+			assert.InDelta(t, coordinates.Lat, p.Y, 1e-9)
+		}
+	}
+	// southern half of the world at zoom 0 is empty
+	assert.Empty(t, c.GetTile(0, 1, 1))
+	assert.NotEmpty(t, c.GetTile(0, 0, 1))
+}
+
+func TestWithProjection_Planar(t *testing.T) {
+	// points of the floorplan image of 1000x1000 pixels
+	points := []cluster.GeoPoint{
+		simplePoint{0, 100, 100},
+		simplePoint{1, 102, 101},
+		simplePoint{2, 900, 850},
+		simplePoint{3, 905, 850},
+		simplePoint{4, 500, 20},
+	}
+
+	c, err := cluster.New(points, cluster.WithinZoom(0, 4), cluster.WithProjection(cluster.Planar{Extent: 1000}))
+	require.NoError(t, err)
+
+	clusters, err := c.GetClusters(&cluster.Point{X: 0, Y: 0}, &cluster.Point{X: 1000, Y: 1000}, 2, -1)
+	require.NoError(t, err)
+	require.Len(t, clusters, 3)
+
+	for _, p := range clusters {
+		switch p.NumPoints {
+		case 2:
+			bounds, err := c.GetClusterBounds(p.ID)
+			require.NoError(t, err)
+
+			if p.X < 500 {
+				assert.InDelta(t, 101, p.X, 1e-9)
+				assert.InDelta(t, 100.5, p.Y, 1e-9)
+				assert.InDelta(t, 100, bounds.MinLng, 1e-9)
+				assert.InDelta(t, 100, bounds.MinLat, 1e-9)
+				assert.InDelta(t, 102, bounds.MaxLng, 1e-9)
+				assert.InDelta(t, 101, bounds.MaxLat, 1e-9)
+			} else {
+				assert.InDelta(t, 902.5, p.X, 1e-9)
+				assert.InDelta(t, 850, p.Y, 1e-9)
+			}
+		default:
+			assert.Equal(t, int64(4), p.ID)
+			assert.Equal(t, 500.0, p.X)
+			assert.Equal(t, 20.0, p.Y)
+		}
+	}
+	// the plane doesn't wrap around, corners are interchangeable
+	clusters, err = c.GetClusters(&cluster.Point{X: 1000, Y: 0}, &cluster.Point{X: 400, Y: 500}, 2, -1)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, int64(4), clusters[0].ID)
+
+	tile := c.GetTile(0, 0, 0)
+	require.Len(t, tile, 3)
+
+	for _, p := range tile {
+		if !p.IsCluster(c) {
+			assert.Equal(t, 256.0, p.X)
+			assert.Equal(t, 10.0, p.Y)
+		}
+	}
+}
+
+// mirrored is a custom projection, mirroring Equirectangular projection horizontally.
+type mirrored struct{}
+
+func (mirrored) Project(coordinates cluster.GeoCoordinates) (float64, float64) {
+	x, y := cluster.Equirectangular{}.Project(coordinates)
+
+	return 1 - x, y
+}
+
+func (mirrored) Unproject(x, y float64) cluster.GeoCoordinates {
+	return cluster.Equirectangular{}.Unproject(1-x, y)
+}
+
+func TestWithProjection_Snapshot(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	for _, projection := range []cluster.Projection{cluster.Equirectangular{}, cluster.Planar{Extent: 360}, mirrored{}} {
+		c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 10), cluster.WithProjection(projection))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+
+		_, err = c.WriteTo(&buf)
+		require.NoError(t, err)
+
+		snapshot := buf.Bytes()
+
+		_, err = cluster.Load(bytes.NewReader(snapshot), geoPoints, cluster.WithProjection(cluster.WebMercator{}))
+		assert.Equal(t, cluster.ErrSnapshotProjectionMismatch, err, "%T", projection)
+
+		loaded, err := cluster.Load(bytes.NewReader(snapshot), geoPoints, cluster.WithProjection(projection))
+		require.NoError(t, err, "%T", projection)
+		assert.Equal(t, c.AllClusters(3, -1), loaded.AllClusters(3, -1), "%T", projection)
+
+		loaded, err = cluster.Load(bytes.NewReader(snapshot), geoPoints)
+		if _, ok := projection.(mirrored); ok {
+			// custom projection can't be restored without the option
+			assert.Equal(t, cluster.ErrSnapshotProjectionMismatch, err)
+
+			continue
+		}
+
+		require.NoError(t, err, "%T", projection)
+		assert.Equal(t, c.AllClusters(3, -1), loaded.AllClusters(3, -1), "%T", projection)
+		assert.Equal(t, c.GetTile(0, 0, 1), loaded.GetTile(0, 0, 1), "%T", projection)
+	}
+
+	_, err := cluster.Load(bytes.NewReader(nil), geoPoints, cluster.WithProjection(nil))
+	assert.Equal(t, cluster.ErrInvalidProjection, err)
+}
+
+func TestWithProjection_PlanarEdgeTiles(t *testing.T) {
+	// points at the western and eastern edges of the plane and the world
+	planar := []cluster.GeoPoint{&cluster.Point{X: 5, Y: 100}, &cluster.Point{X: 1020, Y: 100}}
+	geo := []cluster.GeoPoint{&cluster.Point{X: -179.5, Y: 60}, &cluster.Point{X: 179.5, Y: 60}}
+
+	c, err := cluster.New(planar, cluster.WithinZoom(0, 2), cluster.WithProjection(cluster.Planar{Extent: 1024}))
+	require.NoError(t, err)
+
+	mercator, err := cluster.New(geo, cluster.WithinZoom(0, 2))
+	require.NoError(t, err)
+
+	for _, x := range []int{0, 1} {
+		// the plane doesn't wrap, so the edge tiles don't get points of the opposite edge in their buffers
+		tile := c.GetTile(x, 0, 1)
+		require.Len(t, tile, 1)
+		assert.Equal(t, int64(x), tile[0].ID)
+
+		assert.Len(t, mercator.GetTile(x, 0, 1), 2)
+	}
+
+	assert.Equal(t, []cluster.TileCoordinates{{Z: 1, X: 0, Y: 0}, {Z: 1, X: 1, Y: 0}}, c.NonEmptyTiles(1))
+	assert.Equal(t, []cluster.TileCoordinates{{Z: 2, X: 0, Y: 0}, {Z: 2, X: 3, Y: 0}}, c.NonEmptyTiles(2))
+	assert.Len(t, mercator.NonEmptyTiles(2), 2)
+}
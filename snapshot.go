@@ -0,0 +1,790 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/electrious-go/kdbush"
+)
+
+const (
+	snapshotMagic   = "GOCLUSTR"
+	snapshotVersion = 1
+)
+
+// snapshot flags of the clustering modes.
+const (
+	snapshotStableIDs = 1 << iota
+	snapshotWeightedSeeds
+)
+
+// snapshotCustom is the snapshot kind of custom projections and algorithms, that can't be restored.
+const snapshotCustom = 0
+
+// snapshot kinds of the projections.
+const (
+	snapshotWebMercator = iota + 1
+	snapshotEquirectangular
+	snapshotPlanar
+)
+
+// snapshot kinds of the algorithms.
+const (
+	snapshotGreedy = iota + 1
+	snapshotGrid
+	snapshotDBSCAN
+)
+
+var (
+	ErrInvalidSnapshot            = errors.New("invalid cluster snapshot")
+	ErrUnsupportedSnapshot        = errors.New("unsupported cluster snapshot version")
+	ErrSnapshotPointsMismatch     = errors.New("number of points doesn't match the cluster snapshot")
+	ErrSnapshotProjectionMismatch = errors.New("projection doesn't match the cluster snapshot")
+	ErrSnapshotAlgorithmMismatch  = errors.New("algorithm doesn't match the cluster snapshot")
+)
+
+// WriteTo writes the binary snapshot of the cluster to w, which could be loaded later with Load.
+// Snapshot contains cluster parameters and points of all zoom levels.
+// Original points and aggregated properties are not written.
+// WriteTo implements io.WriterTo interface.
+func (c *Cluster) WriteTo(w io.Writer) (int64, error) {
+	sw := &snapshotWriter{w: bufio.NewWriter(w)}
+
+	sw.bytes([]byte(snapshotMagic))
+	sw.uvarint(snapshotVersion)
+
+	for _, v := range []int{c.MinZoom, c.MaxZoom, c.PointSize, c.TileSize, c.NodeSize, c.numLeaves()} {
+		sw.varint(v)
+	}
+	// radius functions can't be written, so radii of all zoom levels are written instead
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		sw.float64(c.Radius(z))
+	}
+
+	sw.varint(c.MinPoints)
+	sw.varint(int(c.centroid))
+	sw.projection(c.projection)
+	sw.algorithm(c.algorithm)
+	// points are shared between zoom levels, so each point is written once and referenced by its position
+	objects := make(map[*Point]int)
+	levels := make([][]*Point, len(c.Indexes))
+
+	var ordered []*Point
+
+	for i := len(c.Indexes) - 1; i >= 0; i-- {
+		for _, kp := range c.Indexes[i].Points {
+			p := kp.(*Point)
+			// points, removed by incremental updates, are nil until the KD-tree is rebuilt
+			if p == nil {
+				continue
+			}
+
+			levels[i] = append(levels[i], p)
+
+			if _, ok := objects[p]; !ok {
+				objects[p] = len(ordered)
+				ordered = append(ordered, p)
+			}
+		}
+	}
+
+	// groups are shared by many points, so each group is written once and referenced by its position
+	groups := make(map[string]int)
+
+	var groupNames []string
+
+	for _, p := range ordered {
+		if _, ok := groups[p.Group]; !ok {
+			groups[p.Group] = len(groupNames)
+			groupNames = append(groupNames, p.Group)
+		}
+	}
+
+	sw.varint(len(groupNames))
+
+	for _, group := range groupNames {
+		sw.string(group)
+	}
+
+	sw.varint(len(ordered))
+
+	for _, p := range ordered {
+		sw.float64(p.X)
+		sw.float64(p.Y)
+		sw.varint64(p.ID)
+		sw.varint(p.NumPoints)
+		sw.float64(p.Weight)
+		sw.varint(groups[p.Group])
+	}
+
+	sw.varint(len(levels))
+
+	for _, level := range levels {
+		sw.varint(len(level))
+
+		for _, p := range level {
+			sw.varint(objects[p])
+		}
+	}
+	// clusters are written with their children, parents of the points are restored from the children
+	sw.varint(len(c.clusterLevels))
+
+	for _, level := range c.clusterLevels {
+		// clusters, dissolved by incremental updates, are nil until the zoom level is compacted
+		numClusters := 0
+
+		for _, p := range level.points {
+			if p != nil {
+				numClusters++
+			}
+		}
+
+		sw.varint(numClusters)
+
+		for slot, p := range level.points {
+			if p == nil {
+				continue
+			}
+
+			children := level.childrenOf(slot)
+			sw.varint(objects[p])
+			sw.varint(len(children))
+
+			for _, child := range children {
+				sw.varint(objects[child])
+			}
+			// means and priorities are required to position clusters of incremental updates
+			if level.centroids != nil {
+				sw.float64(level.centroids[slot].meanX)
+				sw.float64(level.centroids[slot].meanY)
+				sw.float64(level.centroids[slot].priority)
+			}
+		}
+	}
+	// indexes of the clusters of incremental updates continue indexes of the existing clusters
+	sw.varint(len(c.nextClusterIdx))
+
+	for _, idx := range c.nextClusterIdx {
+		sw.varint(idx)
+	}
+	// new clusters of incremental updates get stable IDs and weighted seeds as well
+	flags := 0
+	if c.stableIDs {
+		flags |= snapshotStableIDs
+	}
+
+	if c.weightedSeeds {
+		flags |= snapshotWeightedSeeds
+	}
+
+	sw.varint(flags)
+
+	if sw.err == nil {
+		sw.err = sw.w.Flush()
+	}
+
+	return sw.n, sw.err
+}
+
+// Load reads the cluster snapshot, written by WriteTo, and restores the cluster without clustering points again.
+// Points must be the same slice of points the snapshot was created from.
+// KD-trees aren't stored, as kdbush doesn't expose their arrays, so they are rebuilt from the restored points.
+// The rebuild takes most of the Load time, which is still about three times faster than clustering,
+// see Benchmark_Load.
+// Options, affecting the clustering, are ignored, as the cluster parameters and radii of all zoom levels
+// are restored from the snapshot. Projection and algorithm are restored from the snapshot as well,
+// custom ones must be provided with WithProjection and WithAlgorithm options.
+// Returns ErrSnapshotProjectionMismatch or ErrSnapshotAlgorithmMismatch if the provided projection or algorithm
+// differs from the one of the snapshot, or custom one of the snapshot is not provided.
+// Aggregated properties are not stored in the snapshot, they are recalculated if WithAggregator option is provided.
+func Load(r io.Reader, points []GeoPoint, opts ...Option) (*Cluster, error) {
+	cluster, err := readSnapshot(r, len(points), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster.Points = points
+	if err := cluster.useGeoPoints(); err != nil {
+		return nil, err
+	}
+
+	if err := cluster.restore(); err != nil {
+		return nil, err
+	}
+
+	return cluster, nil
+}
+
+// readSnapshot restores the cluster of numPoints original points from the snapshot.
+func readSnapshot(r io.Reader, numPoints int, opts []Option) (*Cluster, error) {
+	cluster := &Cluster{}
+
+	for _, opt := range opts {
+		if err := opt(cluster); err != nil {
+			return nil, err
+		}
+	}
+
+	sr := &snapshotReader{r: bufio.NewReader(r)}
+
+	if magic := sr.bytes(len(snapshotMagic)); sr.err == nil && string(magic) != snapshotMagic {
+		return nil, ErrInvalidSnapshot
+	}
+
+	version := sr.uvarint()
+	if sr.err == nil && version != snapshotVersion {
+		return nil, ErrUnsupportedSnapshot
+	}
+
+	cluster.MinZoom = sr.varint()
+	cluster.MaxZoom = sr.varint()
+	cluster.PointSize = sr.varint()
+	cluster.TileSize = sr.varint()
+	cluster.NodeSize = sr.varint()
+	if sr.err == nil && cluster.NodeSize <= 0 {
+		return nil, ErrInvalidSnapshot
+	}
+	cluster.radiusFn = nil
+
+	if n := sr.varint(); sr.err == nil && n != numPoints {
+		return nil, ErrSnapshotPointsMismatch
+	}
+
+	if sr.err != nil {
+		return nil, sr.err
+	}
+
+	if cluster.MaxZoom < cluster.MinZoom || cluster.MaxZoom-cluster.MinZoom > InfinityZoomLevel {
+		return nil, ErrInvalidSnapshot
+	}
+
+	radii := make([]float64, cluster.MaxZoom-cluster.MinZoom+1)
+	for i := range radii {
+		radii[i] = sr.float64()
+	}
+
+	cluster.radiusFn = func(zoom int) float64 {
+		return radii[cluster.LimitZoom(zoom)-cluster.MinZoom]
+	}
+
+	cluster.MinPoints = sr.varint()
+
+	cluster.centroid = CentroidStrategy(sr.varint())
+	if sr.err == nil && !cluster.centroid.valid() {
+		return nil, ErrInvalidSnapshot
+	}
+
+	projection, custom := sr.projection()
+	if sr.err == nil {
+		if err := cluster.restoreProjection(projection, custom); err != nil {
+			return nil, err
+		}
+	}
+
+	algorithm, custom := sr.algorithm()
+	if sr.err == nil {
+		if err := cluster.restoreAlgorithm(algorithm, custom); err != nil {
+			return nil, err
+		}
+	}
+
+	// each zoom level and the original points level hold every point once, either merged or not
+	levels := cluster.MaxZoom - cluster.MinZoom + 2
+
+	groups := make([]string, sr.length(numPoints))
+	for i := range groups {
+		groups[i] = sr.string()
+	}
+
+	objects := make([]*Point, sr.length(numPoints*levels))
+	for i := range objects {
+		p := &Point{}
+		p.X = sr.float64()
+		p.Y = sr.float64()
+		p.ID = sr.varint64()
+		p.NumPoints = sr.varint()
+		p.Weight = sr.float64()
+
+		group := sr.varint()
+		if sr.err == nil && (group < 0 || group >= len(groups)) {
+			return nil, ErrInvalidSnapshot
+		}
+
+		if sr.err == nil {
+			p.Group = groups[group]
+		}
+
+		objects[i] = p
+	}
+
+	if n := sr.length(levels); sr.err == nil && n != levels {
+		return nil, ErrInvalidSnapshot
+	}
+
+	cluster.Indexes = make([]*kdbush.KDBush, levels)
+
+	for i := range cluster.Indexes {
+		level := make([]kdbush.Point, sr.length(numPoints))
+
+		for j := range level {
+			idx := sr.varint()
+			if sr.err != nil {
+				return nil, sr.err
+			}
+
+			if idx < 0 || idx >= len(objects) {
+				return nil, ErrInvalidSnapshot
+			}
+
+			level[j] = objects[idx]
+		}
+
+		cluster.Indexes[i] = kdbush.NewBush(level, cluster.NodeSize)
+	}
+
+	if n := sr.length(levels - 1); sr.err == nil && n != levels-1 {
+		return nil, ErrInvalidSnapshot
+	}
+
+	cluster.clusterLevels = make([]levelClusters, levels-1)
+
+	for i := range cluster.clusterLevels {
+		if err := sr.clusters(&cluster.clusterLevels[i], objects, numPoints, cluster.centroid != CentroidWeightedMean); err != nil {
+			return nil, err
+		}
+	}
+
+	if n := sr.length(levels - 1); n > 0 {
+		if sr.err == nil && n != levels-1 {
+			return nil, ErrInvalidSnapshot
+		}
+
+		cluster.nextClusterIdx = make([]int, n)
+
+		for i := range cluster.nextClusterIdx {
+			cluster.nextClusterIdx[i] = sr.varint()
+		}
+	}
+
+	flags := sr.varint()
+	cluster.stableIDs = flags&snapshotStableIDs != 0
+	cluster.weightedSeeds = flags&snapshotWeightedSeeds != 0
+
+	if sr.err != nil {
+		return nil, sr.err
+	}
+
+	if cluster.nextClusterIdx != nil || cluster.stableIDs {
+		// slots of clusters don't match their IDs after incremental updates or with stable IDs
+		cluster.indexClusters()
+	}
+
+	return cluster, nil
+}
+
+// restore checks the clusters hierarchy, read from the snapshot, and sets boxes, hashes and aggregated properties
+// of the cluster, once access to the original points is set.
+func (c *Cluster) restore() error {
+	if !c.checkClusters() {
+		return ErrInvalidSnapshot
+	}
+
+	c.boundClusters()
+
+	if c.stableIDs {
+		c.hashClusters()
+	}
+
+	if c.mapLeaf != nil {
+		c.aggregate()
+	}
+
+	return nil
+}
+
+// checkClusters checks the clusters hierarchy, e.g. restored from a corrupted snapshot, is consistent,
+// so walking the hierarchy down from any cluster reaches exactly its number of the original points.
+func (c *Cluster) checkClusters() bool {
+	numLeaves := int64(c.numLeaves())
+	leaves := len(c.Indexes[len(c.Indexes)-1].Points)
+	// point is either the original point or the cluster, created at the zoom level or a higher one
+	valid := func(p *Point, zoom int) bool {
+		if p.ID < clusterFlag {
+			return p.ID >= 0 && p.ID < numLeaves && p.NumPoints == 1
+		}
+
+		origin, originZoom, err := c.getCluster(p.ID)
+
+		return err == nil && origin == p && originZoom >= zoom && p.NumPoints >= 1 && p.NumPoints <= leaves
+	}
+
+	for i, index := range c.Indexes {
+		for _, kp := range index.Points {
+			if !valid(kp.(*Point), c.MinZoom+i) {
+				return false
+			}
+		}
+	}
+
+	total := 0
+
+	for _, kp := range c.Indexes[0].Points {
+		total += kp.(*Point).NumPoints
+	}
+
+	if total != leaves {
+		return false
+	}
+	// children of the cluster are created at higher zoom levels and consist of the cluster points
+	for i, level := range c.clusterLevels {
+		zoom := c.MinZoom + i
+
+		for slot, p := range level.points {
+			if !valid(p, zoom) || clusterZoom(p.ID) != zoom {
+				return false
+			}
+			// new clusters of incremental updates must not reuse indexes of the existing ones
+			if _, index, _ := DecodeClusterID(p.ID); c.nextClusterIdx != nil && !c.stableIDs &&
+				index >= c.nextClusterIdx[i] {
+				return false
+			}
+
+			numPoints := 0
+
+			for _, child := range level.childrenOf(slot) {
+				if !valid(child, zoom+1) {
+					return false
+				}
+
+				numPoints += child.NumPoints
+			}
+
+			if numPoints != p.NumPoints {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// restoreProjection sets the projection of the snapshot, or checks the projection, provided with the option, matches it.
+func (c *Cluster) restoreProjection(projection Projection, custom bool) error {
+	switch {
+	case c.projection == nil && !custom:
+		c.projection = projection
+	case custom && (c.projection == nil || snapshotProjectionKind(c.projection) != snapshotCustom):
+		return ErrSnapshotProjectionMismatch
+	case !custom && c.projection != projection:
+		return ErrSnapshotProjectionMismatch
+	}
+
+	return nil
+}
+
+// snapshotProjectionKind returns the snapshot kind of the projection.
+func snapshotProjectionKind(projection Projection) int {
+	switch projection.(type) {
+	case WebMercator:
+		return snapshotWebMercator
+	case Equirectangular:
+		return snapshotEquirectangular
+	case Planar:
+		return snapshotPlanar
+	default:
+		return snapshotCustom
+	}
+}
+
+// restoreAlgorithm sets the algorithm of the snapshot, or checks the algorithm, provided with the option, matches it.
+func (c *Cluster) restoreAlgorithm(algorithm Algorithm, custom bool) error {
+	switch {
+	case c.algorithm == nil && !custom:
+		c.algorithm = algorithm
+	case custom && (c.algorithm == nil || snapshotAlgorithmKind(c.algorithm) != snapshotCustom):
+		return ErrSnapshotAlgorithmMismatch
+	case !custom && c.algorithm != algorithm:
+		return ErrSnapshotAlgorithmMismatch
+	}
+
+	return nil
+}
+
+// snapshotAlgorithmKind returns the snapshot kind of the algorithm.
+func snapshotAlgorithmKind(algorithm Algorithm) int {
+	switch algorithm.(type) {
+	case Greedy:
+		return snapshotGreedy
+	case Grid:
+		return snapshotGrid
+	case DBSCAN:
+		return snapshotDBSCAN
+	default:
+		return snapshotCustom
+	}
+}
+
+// aggregate calculates properties of all points and clusters, from the lowest zoom level to the top one.
+func (c *Cluster) aggregate() {
+	leaves := c.Indexes[len(c.Indexes)-1]
+	for _, kp := range leaves.Points {
+		p := kp.(*Point)
+		p.Properties = c.mapLeaf(int(p.ID))
+	}
+
+	for level := len(c.clusterLevels) - 1; level >= 0; level-- {
+		for slot, p := range c.clusterLevels[level].points {
+			for i, child := range c.clusterLevels[level].childrenOf(slot) {
+				if i == 0 {
+					p.Properties = child.Properties
+				} else {
+					p.Properties = c.reduceFn(p.Properties, child.Properties)
+				}
+			}
+		}
+	}
+}
+
+// snapshotWriter writes snapshot values, keeping the first error and number of written bytes.
+type snapshotWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (sw *snapshotWriter) bytes(b []byte) {
+	if sw.err != nil {
+		return
+	}
+
+	n, err := sw.w.Write(b)
+	sw.n += int64(n)
+	sw.err = err
+}
+
+func (sw *snapshotWriter) uvarint(v uint64) {
+	sw.bytes(sw.buf[:binary.PutUvarint(sw.buf[:], v)])
+}
+
+func (sw *snapshotWriter) varint64(v int64) {
+	sw.bytes(sw.buf[:binary.PutVarint(sw.buf[:], v)])
+}
+
+func (sw *snapshotWriter) varint(v int) {
+	sw.varint64(int64(v))
+}
+
+func (sw *snapshotWriter) string(v string) {
+	sw.varint(len(v))
+	sw.bytes([]byte(v))
+}
+
+func (sw *snapshotWriter) float64(v float64) {
+	binary.LittleEndian.PutUint64(sw.buf[:8], math.Float64bits(v))
+	sw.bytes(sw.buf[:8])
+}
+
+// projection writes the kind of the projection, followed by its parameters.
+func (sw *snapshotWriter) projection(projection Projection) {
+	sw.varint(snapshotProjectionKind(projection))
+
+	if planar, ok := projection.(Planar); ok {
+		sw.float64(planar.Extent)
+	}
+}
+
+// algorithm writes the kind of the algorithm, followed by its parameters.
+func (sw *snapshotWriter) algorithm(algorithm Algorithm) {
+	sw.varint(snapshotAlgorithmKind(algorithm))
+
+	if dbscan, ok := algorithm.(DBSCAN); ok {
+		sw.varint(dbscan.MinPoints)
+	}
+}
+
+// snapshotReader reads snapshot values, keeping the first error.
+// Zero values are returned after the error.
+type snapshotReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (sr *snapshotReader) bytes(n int) []byte {
+	b := make([]byte, n)
+	if sr.err != nil {
+		return b
+	}
+
+	if _, err := io.ReadFull(sr.r, b); err != nil {
+		sr.setErr(err)
+	}
+
+	return b
+}
+
+func (sr *snapshotReader) uvarint() uint64 {
+	if sr.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadUvarint(sr.r)
+	sr.setErr(err)
+
+	return v
+}
+
+func (sr *snapshotReader) varint64() int64 {
+	if sr.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadVarint(sr.r)
+	sr.setErr(err)
+
+	return v
+}
+
+func (sr *snapshotReader) varint() int {
+	return int(sr.varint64())
+}
+
+// length reads the length of the following sequence, limited by max to prevent huge allocations for corrupted data.
+func (sr *snapshotReader) length(max int) int {
+	n := sr.varint()
+	if n < 0 || n > max {
+		sr.setErr(ErrInvalidSnapshot)
+
+		return 0
+	}
+
+	return n
+}
+
+// string reads the string, growing the buffer as the data is read, as the length could be corrupted.
+func (sr *snapshotReader) string() string {
+	n := sr.length(math.MaxInt32)
+	if sr.err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	if _, err := io.CopyN(&b, sr.r, int64(n)); err != nil {
+		sr.setErr(err)
+	}
+
+	return b.String()
+}
+
+func (sr *snapshotReader) float64() float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(sr.bytes(8)))
+}
+
+// projection reads the projection of the snapshot, custom is true for the projection, that can't be restored.
+func (sr *snapshotReader) projection() (projection Projection, custom bool) {
+	switch sr.varint() {
+	case snapshotWebMercator:
+		return WebMercator{}, false
+	case snapshotEquirectangular:
+		return Equirectangular{}, false
+	case snapshotPlanar:
+		return Planar{Extent: sr.float64()}, false
+	case snapshotCustom:
+		return nil, true
+	default:
+		sr.setErr(ErrInvalidSnapshot)
+
+		return nil, false
+	}
+}
+
+// algorithm reads the algorithm of the snapshot, custom is true for the algorithm, that can't be restored.
+func (sr *snapshotReader) algorithm() (algorithm Algorithm, custom bool) {
+	switch sr.varint() {
+	case snapshotGreedy:
+		return Greedy{}, false
+	case snapshotGrid:
+		return Grid{}, false
+	case snapshotDBSCAN:
+		return DBSCAN{MinPoints: sr.varint()}, false
+	case snapshotCustom:
+		return nil, true
+	default:
+		sr.setErr(ErrInvalidSnapshot)
+
+		return nil, false
+	}
+}
+
+// clusters reads clusters of the zoom level with their children, referenced by their positions in objects.
+// Parents of the children are set to the clusters, each point could be merged into one cluster only.
+// Centroids of the clusters follow their children, if they are kept.
+func (sr *snapshotReader) clusters(level *levelClusters, objects []*Point, numPoints int, centroids bool) error {
+	object := func() (*Point, error) {
+		idx := sr.varint()
+		if sr.err != nil {
+			return nil, sr.err
+		}
+
+		if idx < 0 || idx >= len(objects) {
+			return nil, ErrInvalidSnapshot
+		}
+
+		return objects[idx], nil
+	}
+
+	n := sr.length(numPoints)
+	level.points = make([]*Point, n)
+	level.ends = make([]int, n)
+
+	if centroids {
+		level.centroids = make([]centroid, n)
+	}
+
+	for slot := range level.points {
+		cluster, err := object()
+		if err != nil {
+			return err
+		}
+
+		children := sr.length(numPoints - len(level.children))
+		for i := 0; i < children; i++ {
+			child, err := object()
+			if err != nil {
+				return err
+			}
+
+			if child.parentID != 0 {
+				return ErrInvalidSnapshot
+			}
+
+			child.parentID = cluster.ID
+			level.children = append(level.children, child)
+		}
+
+		level.points[slot] = cluster
+		level.ends[slot] = len(level.children)
+
+		if centroids {
+			level.centroids[slot] = centroid{meanX: sr.float64(), meanY: sr.float64(), priority: sr.float64()}
+		}
+	}
+
+	return sr.err
+}
+
+func (sr *snapshotReader) setErr(err error) {
+	if sr.err != nil || err == nil {
+		return
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	sr.err = err
+}
@@ -0,0 +1,258 @@
+package cluster_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_WriteToLoadRadiusFunc(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 10), cluster.WithMinPoints(3), cluster.WithRadiusFunc(func(zoom int) float64 {
+		return float64(100 - 8*zoom)
+	}))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err := cluster.Load(&buf, geoPoints)
+	require.NoError(t, err)
+	assertSameIndexes(t, c, loaded)
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		assert.Equal(t, c.Radius(z), loaded.Radius(z))
+		assert.Equal(t, c.GetTile(1, 0, z), loaded.GetTile(1, 0, z))
+	}
+
+	assert.Equal(t, c.Radius(10), loaded.Radius(15))
+	assert.Equal(t, 3, loaded.MinPoints)
+	assertHierarchy(t, loaded, len(geoPoints))
+}
+
+func TestCluster_WriteToLoad(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+	require.NotEmpty(t, geoPoints)
+
+	sum := cluster.WithAggregator(
+		func(p cluster.GeoPoint) interface{} {
+			return 1
+		},
+		func(accumulated, props interface{}) interface{} {
+			return accumulated.(int) + props.(int)
+		})
+
+	c, err := cluster.New(geoPoints[:150], cluster.WithinZoom(1, 15), cluster.WithPointSize(60), sum)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	n, err := c.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	loaded, err := cluster.Load(bytes.NewReader(buf.Bytes()), geoPoints[:150], sum)
+	require.NoError(t, err)
+
+	assert.Equal(t, c.MinZoom, loaded.MinZoom)
+	assert.Equal(t, c.MaxZoom, loaded.MaxZoom)
+	assert.Equal(t, c.PointSize, loaded.PointSize)
+
+	for z := c.MinZoom; z <= c.MaxZoom+1; z++ {
+		expected := c.AllClusters(z, -1)
+		actual := loaded.AllClusters(z, -1)
+		require.Equal(t, len(expected), len(actual))
+
+		for i := range expected {
+			assert.Equal(t, expected[i].ID, actual[i].ID)
+			assert.Equal(t, expected[i].NumPoints, actual[i].NumPoints)
+			assert.Equal(t, expected[i].Included(c), actual[i].Included(loaded))
+			assert.Equal(t, expected[i].NumPoints, actual[i].Properties)
+		}
+	}
+
+	assert.Equal(t, c.GetTile(0, 0, 1), loaded.GetTile(0, 0, 1))
+	assertHierarchy(t, loaded, 150)
+
+	// snapshot after incremental updates
+	c.Insert(geoPoints[150:]...)
+	c.Remove(3, 4)
+	buf.Reset()
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err = cluster.Load(bytes.NewReader(buf.Bytes()), c.Points)
+	require.NoError(t, err)
+	assertHierarchy(t, loaded, len(geoPoints)-2)
+
+	_, err = cluster.Load(bytes.NewReader(buf.Bytes()), geoPoints[:100])
+	assert.Equal(t, cluster.ErrSnapshotPointsMismatch, err)
+
+	_, err = cluster.Load(bytes.NewReader(buf.Bytes()[:buf.Len()/2]), c.Points)
+	assert.Error(t, err)
+
+	_, err = cluster.Load(bytes.NewReader([]byte("NOTACLUSTER")), c.Points)
+	assert.Equal(t, cluster.ErrInvalidSnapshot, err)
+}
+
+func TestCluster_WriteToLoadStableIDs(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+
+	c, err := cluster.New(geoPoints[:150], cluster.WithinZoom(0, 14), cluster.WithStableIDs())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err := cluster.Load(&buf, geoPoints[:150])
+	require.NoError(t, err)
+	assertSameIndexes(t, c, loaded)
+
+	// updates of the loaded cluster keep assigning stable IDs
+	c.Insert(geoPoints[150:]...)
+	loaded.Insert(geoPoints[150:]...)
+	assertSameIndexes(t, c, loaded)
+	assertHierarchy(t, loaded, len(geoPoints))
+}
+
+func TestCluster_WriteToLoadGroups(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+	group := cluster.WithGroupBy(func(p cluster.GeoPoint) string {
+		return []string{"", "bar", "cafe"}[p.GetID()%3]
+	})
+
+	c, err := cluster.New(geoPoints[:150], cluster.WithinZoom(0, 14), group)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err := cluster.Load(&buf, geoPoints[:150], group)
+	require.NoError(t, err)
+	assertSameIndexes(t, c, loaded)
+
+	c.Insert(geoPoints[150:]...)
+	loaded.Insert(geoPoints[150:]...)
+	assertSameIndexes(t, c, loaded)
+}
+
+// snapshotHeader returns the snapshot of a single zoom level Greedy cluster in WebMercator projection
+// up to the number of groups.
+func snapshotHeader(nodeSize, numPoints int) []byte {
+	b := []byte("GOCLUSTR")
+	b = append(b, 1)
+
+	var buf [binary.MaxVarintLen64]byte
+	for _, v := range []int64{0, 0, 40, 512, int64(nodeSize), int64(numPoints)} {
+		b = append(b, buf[:binary.PutVarint(buf[:], v)]...)
+	}
+
+	b = append(b, make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(b[len(b)-8:], math.Float64bits(40))
+
+	// MinPoints, centroid strategy, projection and algorithm
+	for _, v := range []int64{2, 0, 1, 1} {
+		b = append(b, buf[:binary.PutVarint(buf[:], v)]...)
+	}
+
+	return b
+}
+
+func TestCluster_LoadCorrupted(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")[:50]
+
+	c, err := cluster.New(geoPoints, cluster.WithinZoom(0, 6), cluster.WithCentroidStrategy(cluster.CentroidMedoid))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	snapshot := buf.Bytes()
+
+	for i := range snapshot {
+		corrupted := append([]byte(nil), snapshot...)
+		corrupted[i] ^= 0xff
+
+		assert.NotPanics(t, func() {
+			_, _ = cluster.Load(bytes.NewReader(corrupted), geoPoints)
+		})
+
+		_, err := cluster.Load(bytes.NewReader(snapshot[:i]), geoPoints)
+		assert.Error(t, err)
+	}
+
+	var varint [binary.MaxVarintLen64]byte
+
+	// zero node size
+	_, err = cluster.Load(bytes.NewReader(snapshotHeader(0, len(geoPoints))), geoPoints)
+	assert.Equal(t, cluster.ErrInvalidSnapshot, err)
+
+	// length of the groups exceeding the number of points
+	huge := snapshotHeader(64, len(geoPoints))
+	huge = append(huge, varint[:binary.PutVarint(varint[:], 1<<40)]...)
+	_, err = cluster.Load(bytes.NewReader(huge), geoPoints)
+	assert.Equal(t, cluster.ErrInvalidSnapshot, err)
+
+	// length of the objects exceeding the number of points of all levels
+	huge = append(snapshotHeader(64, len(geoPoints)), 0)
+	huge = append(huge, varint[:binary.PutVarint(varint[:], int64(2*len(geoPoints)+1))]...)
+	_, err = cluster.Load(bytes.NewReader(huge), geoPoints)
+	assert.Equal(t, cluster.ErrInvalidSnapshot, err)
+}
+
+func FuzzLoad(f *testing.F) {
+	geoPoints := importSimplePoints("./testdata/places.json")[:20]
+
+	for _, opts := range [][]cluster.Option{
+		{cluster.WithinZoom(0, 4)},
+		{cluster.WithinZoom(0, 4), cluster.WithStableIDs(), cluster.WithCentroidStrategy(cluster.CentroidMedoid)},
+	} {
+		c, err := cluster.New(geoPoints, opts...)
+		require.NoError(f, err)
+
+		var buf bytes.Buffer
+
+		_, err = c.WriteTo(&buf)
+		require.NoError(f, err)
+		f.Add(buf.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, snapshot []byte) {
+		_, _ = cluster.Load(bytes.NewReader(snapshot), geoPoints)
+	})
+}
+
+func Benchmark_Load(b *testing.B) {
+	points := randomPoints(100000, 20, 5)
+
+	c, err := cluster.New(points)
+	require.NoError(b, err)
+
+	var buf bytes.Buffer
+
+	_, err = c.WriteTo(&buf)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := cluster.Load(bytes.NewReader(buf.Bytes()), points)
+		require.NoError(b, err)
+	}
+}
@@ -1,94 +1,162 @@
 package cluster
 
-import "github.com/electrious-go/kdbush"
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var ErrInvalidTile = errors.New("invalid tile coordinates")
+
+// TileCoordinates are coordinates of a tile in XYZ scheme.
+type TileCoordinates struct {
+	Z, X, Y int
+}
 
 // GetTile return points for  Tile with coordinates x and y and for zoom z
 // return objects with pixel coordinates.
-func (c *Cluster) GetTile(x, y, z int) []Point {
-	return c.getTile(x, y, z, false)
+// If groups are provided, only points and clusters of these groups are returned, see WithGroupBy.
+func (c *Cluster) GetTile(x, y, z int, groups ...string) []Point {
+	return c.getTile(x, y, z, false, c.TileSize, groups)
 }
 
 // GetTileWithLatLng return points for  Tile with coordinates x and y and for zoom z
 // return objects with LatLng coordinates.
-func (c *Cluster) GetTileWithLatLng(x, y, z int) []Point {
-	return c.getTile(x, y, z, true)
+// If groups are provided, only points and clusters of these groups are returned, see WithGroupBy.
+func (c *Cluster) GetTileWithLatLng(x, y, z int, groups ...string) []Point {
+	return c.getTile(x, y, z, true, c.TileSize, groups)
+}
+
+// getTile returns points of the groups in the tile, pixel coordinates are calculated for the tile of extent size.
+func (c *Cluster) getTile(x, y, z int, latLng bool, extent int, groups []string) []Point {
+	var result []Point
+
+	c.eachTilePoint(x, y, z, groups, func(p *Point, tileX float64) {
+		if latLng {
+			result = append(result, c.geoPoint(p))
+
+			return
+		}
+
+		cp := *p
+		cp.X, cp.Y = tilePixel(p.X, p.Y, tileX, float64(y), z, extent)
+		result = append(result, cp)
+	})
+
+	return result
 }
 
-func (c *Cluster) getTile(x, y, z int, latLng bool) []Point {
-	index := c.Indexes[c.LimitZoom(z)-c.MinZoom]
+// eachTilePoint calls fn for points of the groups in the tile with coordinates x and y and for zoom z,
+// and in the buffer around the tile, which is the clustering radius of the tile points.
+// Points are wrapped around the antimeridian for the edge tiles, so tileX is x of the tile
+// in the coordinates of the point, e.g. -1 for points of the western edge in the buffer of the eastern tile,
+// unless the world of the projection doesn't wrap, see WrappingProjection.
+func (c *Cluster) eachTilePoint(x, y, z int, groups []string, fn func(p *Point, tileX float64)) {
+	level := c.LimitZoom(z) - c.MinZoom
+	index := c.Indexes[level]
 	z2 := 1 << uint(z)
 	z2f := float64(z2)
-	extent := c.TileSize
-	r := c.PointSize
-	p := float64(r) / float64(extent)
+	p := c.Radius(c.LimitZoom(z)) / float64(c.TileSize)
 	top := (float64(y) - p) / z2f
 	bottom := (float64(y) + 1 + p) / z2f
-	resultIds := index.Range((float64(x)-p)/z2f, top, (float64(x)+1+p)/z2f, bottom)
 
-	var result []Point
+	each := func(minX, maxX, tileX float64) {
+		box := worldBox{minX: minX, minY: top, maxX: maxX, maxY: bottom}
+		for _, i := range inGroups(c.rangeLevel(level, box), index.Points, groups) {
+			fn(index.Points[i].(*Point), tileX)
+		}
+	}
+
+	each((float64(x)-p)/z2f, (float64(x)+1+p)/z2f, float64(x))
 
-	if latLng == true {
-		result = c.pointIDToLatLngPoint(resultIds, index.Points)
-	} else {
-		result = c.pointIDToMercatorPoint(resultIds, index.Points, float64(x), float64(y), z2f)
+	if !c.wraps() {
+		return
 	}
+
 	if x == 0 {
-		minX1 := (1 - p) / z2f
-		minY1 := top
-		maxX1 := 1.0
-		maxY1 := bottom
-		resultIds = index.Range(minX1, minY1, maxX1, maxY1)
-		var sr1 []Point
-
-		if latLng == true {
-			sr1 = c.pointIDToLatLngPoint(resultIds, index.Points)
-		} else {
-			sr1 = c.pointIDToMercatorPoint(resultIds, index.Points, z2f, float64(y), z2f)
+		each((1-p)/z2f, 1, z2f)
+	}
+
+	if x == z2-1 {
+		each(0, p/z2f, -1)
+	}
+}
+
+// tilePixel returns pixel coordinates of world coordinates in the tile x, y of extent size at zoom z.
+func tilePixel(mx, my, x, y float64, z, extent int) (float64, float64) {
+	z2 := float64(int(1) << uint(z))
+
+	return float64(round(float64(extent) * (mx*z2 - x))), float64(round(float64(extent) * (my*z2 - y)))
+}
+
+// NonEmptyTiles returns coordinates of all tiles of zoom level z, for which GetTile returns at least one point,
+// ordered by x and y. Tiles, having only points of the neighbour tiles in the buffer, are included as well.
+func (c *Cluster) NonEmptyTiles(z int) []TileCoordinates {
+	if z < 0 || z > 30 {
+		return nil
+	}
+
+	z2 := 1 << uint(z)
+	z2f := float64(z2)
+	p := c.Radius(c.LimitZoom(z)) / float64(c.TileSize)
+	wraps := c.wraps()
+	candidates := make(map[TileCoordinates]bool)
+	add := func(x, y int) {
+		if wraps {
+			x = (x%z2 + z2) % z2
+		} else if x < 0 || x >= z2 {
+			return
 		}
-		result = append(result, sr1...)
+
+		candidates[TileCoordinates{Z: z, X: x, Y: y}] = true
 	}
-	if x == (z2 - 1) {
-		minX2 := 0.0
-		minY2 := top
-		maxX2 := p / z2f
-		maxY2 := bottom
-		resultIds = index.Range(minX2, minY2, maxX2, maxY2)
-		var sr2 []Point
-
-		if latLng == true {
-			sr2 = c.pointIDToLatLngPoint(resultIds, index.Points)
-		} else {
-			sr2 = c.pointIDToMercatorPoint(resultIds, index.Points, -1, float64(y), z2f)
+
+	for _, kp := range c.Indexes[c.LimitZoom(z)-c.MinZoom].Points {
+		point := kp.(*Point)
+		if point == nil {
+			continue
+		}
+
+		minY := int(math.Max(0, math.Floor(point.Y*z2f-p)))
+		maxY := int(math.Min(z2f-1, math.Floor(point.Y*z2f+p)))
+
+		for y := minY; y <= maxY; y++ {
+			for x := int(math.Floor(point.X*z2f - p)); x <= int(math.Floor(point.X*z2f+p)); x++ {
+				add(x, y)
+			}
+			// the first tile of the row wraps around the antimeridian wider than the buffer
+			if wraps && point.X >= (1-p)/z2f {
+				add(0, y)
+			}
 		}
-		result = append(result, sr2...)
 	}
-	return result
-}
 
-// pointIDToMercatorPoint calc Point mercator projection regarding tile.
-func (c *Cluster) pointIDToMercatorPoint(ids []int, points []kdbush.Point, x, y, z2 float64) []Point {
-	var result []Point
-	for i := range ids {
-		p := points[ids[i]].(*Point)
-		cp := *p
-		// translate coordinate system to mercator
-		cp.X = float64(round(float64(c.TileSize) * (p.X*z2 - x)))
-		cp.Y = float64(round(float64(c.TileSize) * (p.Y*z2 - y)))
-		cp.zoom = 0
-		result = append(result, cp)
+	tiles := make([]TileCoordinates, 0, len(candidates))
+
+	for t := range candidates {
+		if len(c.getTile(t.X, t.Y, t.Z, true, c.TileSize, nil)) > 0 {
+			tiles = append(tiles, t)
+		}
 	}
-	return result
+
+	sort.Slice(tiles, func(i, j int) bool {
+		if tiles[i].X != tiles[j].X {
+			return tiles[i].X < tiles[j].X
+		}
+
+		return tiles[i].Y < tiles[j].Y
+	})
+
+	return tiles
 }
 
-func (c *Cluster) pointIDToLatLngPoint(ids []int, points []kdbush.Point) []Point {
-	result := make([]Point, len(ids))
-	for i := range ids {
-		p := points[ids[i]].(*Point)
-		cp := *p
-		coordinates := ReverseMercatorProjection(cp.X, cp.Y)
-		cp.X = coordinates.Lng
-		cp.Y = coordinates.Lat
-		result[i] = cp
+// validTile checks that tile coordinates are within the zoom level.
+func validTile(x, y, z int) bool {
+	if z < 0 || z > 30 {
+		return false
 	}
-	return result
+
+	z2 := 1 << uint(z)
+
+	return x >= 0 && x < z2 && y >= 0 && y < z2
 }
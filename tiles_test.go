@@ -2,10 +2,12 @@ package cluster_test
 
 import (
 	"fmt"
+	"sort"
 	"testing"
 
 	cluster "github.com/aliakseiz/gocluster"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCluster_GetTile00(t *testing.T) {
@@ -28,13 +30,75 @@ func TestCluster_GetTile00(t *testing.T) {
 	assert.NotEmpty(t, result)
 
 	expectedPoints := importPoints("./testdata/expect_tile0_0_0.json")
-	// Included field value is tested separately
-	for i := range result {
-		result[i].Included = nil
-	}
 	assert.Equal(t, expectedPoints, result)
 }
 
+// TestCluster_GetTile00IDs checks IDs of the fixture, which encode zoom levels the clusters are created at
+// and indexes of the clusters in the order of creation at those zoom levels.
+func TestCluster_GetTile00IDs(t *testing.T) {
+	points := importData("./testdata/places.json")
+	geoPoints := make([]cluster.GeoPoint, len(points))
+
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	c, err := cluster.New(geoPoints,
+		cluster.WithinZoom(0, 3),
+		cluster.WithPointSize(60),
+		cluster.WithTileSize(256),
+		cluster.WithNodeSize(64))
+	require.NoError(t, err)
+
+	// indexes of the clusters, created at each zoom level, are 0..k-1
+	created := make(map[int]int)
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		var indexes []int
+
+		for _, p := range c.AllClusters(z, -1) {
+			if zoom, index, isCluster := cluster.DecodeClusterID(p.ID); isCluster && zoom == z {
+				indexes = append(indexes, index)
+			}
+		}
+
+		sort.Ints(indexes)
+
+		for i, index := range indexes {
+			require.Equal(t, i, index, "index of the cluster created at zoom %d", z)
+		}
+
+		created[z] = len(indexes)
+	}
+
+	for _, expected := range importPoints("./testdata/expect_tile0_0_0.json") {
+		zoom, index, isCluster := cluster.DecodeClusterID(expected.ID)
+		if expected.NumPoints == 1 {
+			assert.False(t, isCluster)
+			assert.Equal(t, int(expected.ID), index)
+
+			continue
+		}
+
+		require.True(t, isCluster, "cluster %d", expected.ID)
+		require.GreaterOrEqual(t, zoom, c.MinZoom)
+		require.LessOrEqual(t, zoom, c.MaxZoom)
+		assert.Less(t, index, created[zoom], "index of cluster %d", expected.ID)
+
+		found := false
+
+		for _, p := range c.AllClusters(zoom, -1) {
+			if p.ID == expected.ID {
+				found = true
+
+				assert.Equal(t, expected.NumPoints, p.NumPoints)
+			}
+		}
+
+		assert.True(t, found, "cluster %d at zoom %d", expected.ID, zoom)
+	}
+}
+
 // validate original result from JS library.
 func TestCluster_GetTileDefault(t *testing.T) {
 	points := importData("./testdata/places.json")
@@ -63,6 +127,29 @@ func TestCluster_GetTileDefault(t *testing.T) {
 	}
 }
 
+func TestCluster_NonEmptyTiles(t *testing.T) {
+	c, err := cluster.New(importSimplePoints("./testdata/places.json"), cluster.WithinZoom(0, 4))
+	require.NoError(t, err)
+
+	// tiles above MaxZoom contain single points
+	for z := 0; z <= 6; z++ {
+		var expected []cluster.TileCoordinates
+
+		for x := 0; x < 1<<uint(z); x++ {
+			for y := 0; y < 1<<uint(z); y++ {
+				if len(c.GetTile(x, y, z)) > 0 {
+					expected = append(expected, cluster.TileCoordinates{Z: z, X: x, Y: y})
+				}
+			}
+		}
+
+		assert.Equal(t, expected, c.NonEmptyTiles(z), "zoom %d", z)
+	}
+
+	assert.Empty(t, c.NonEmptyTiles(-1))
+	assert.Empty(t, c.NonEmptyTiles(31))
+}
+
 func ExampleCluster_GetTile() {
 	points := importData("./testdata/places.json")
 	geoPoints := make([]cluster.GeoPoint, len(points))
@@ -78,6 +165,10 @@ func ExampleCluster_GetTile() {
 		cluster.WithNodeSize(64))
 	result := c.GetTile(0, 0, 4)
 
-	fmt.Printf("%+v", result)
-	// Output: [{X:-3350 Y:253 zoom:0 ID:22 NumPoints:1 Included:[0]} {X:-2418 Y:165 zoom:0 ID:62 NumPoints:1 Included:[0]}]
+	for _, p := range result {
+		fmt.Printf("X:%v Y:%v ID:%d NumPoints:%d Weight:%v\n", p.X, p.Y, p.ID, p.NumPoints, p.Weight)
+	}
+	// Output:
+	// X:-3350 Y:253 ID:22 NumPoints:1 Weight:1
+	// X:-2418 Y:165 ID:62 NumPoints:1 Weight:1
 }
@@ -0,0 +1,384 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrInvalidCoordinatesFunc = errors.New("coordinates function must be provided")
+
+// CoordinatesFunc returns longitude and latitude of the point.
+// Points, for which ok is false, have no coordinates and are skipped.
+type CoordinatesFunc[T any] func(p T) (lng, lat float64, ok bool)
+
+// TypedPoint is the original point of TypedCluster, passed as GeoPoint to LeafProperties and WithAggregator functions.
+type TypedPoint[T any] struct {
+	// ID is the position of the point in Points slice of TypedCluster
+	ID          int64
+	Value       T
+	Coordinates GeoCoordinates
+}
+
+// GetID to be compatible with interface.
+func (p *TypedPoint[T]) GetID() int64 {
+	return p.ID
+}
+
+// GetCoordinates to be compatible with interface.
+func (p *TypedPoint[T]) GetCoordinates() *GeoCoordinates {
+	return &GeoCoordinates{
+		Lng: p.Coordinates.Lng,
+		Lat: p.Coordinates.Lat,
+	}
+}
+
+// TypedCluster is the Cluster of values of any type, located with CoordinatesFunc instead of GeoPoint interface.
+// Original points are returned as values of the type, without boxing them into interfaces.
+// IDs of single points are positions in Points slice.
+// Cluster is not embedded, so its methods, taking GeoPoint values, can't be mixed with the typed points,
+// while all other methods of Cluster are available.
+type TypedCluster[T any] struct {
+	// Points keeps original slice of given points
+	Points      []T
+	cluster     *Cluster
+	coordinates CoordinatesFunc[T]
+}
+
+// NewTyped creates new TypedCluster instance with default params, same as New.
+// Coordinates of each point are obtained with coordinates function once.
+// Points are not copied in favor of memory efficiency.
+func NewTyped[T any](points []T, coordinates CoordinatesFunc[T], opts ...Option) (*TypedCluster[T], error) {
+	if coordinates == nil {
+		return nil, ErrInvalidCoordinatesFunc
+	}
+
+	cluster, err := configure(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &TypedCluster[T]{Points: points, cluster: cluster, coordinates: coordinates}
+	if err := c.useTypedPoints(); err != nil {
+		return nil, err
+	}
+
+	cluster.build(translatePoints(points, 0, coordinates, cluster.projection, cluster.mapLeaf))
+
+	return c, nil
+}
+
+// LoadTyped reads the cluster snapshot, written by WriteTo, same as Load.
+// Points must be the same slice of points the snapshot was created from.
+func LoadTyped[T any](r io.Reader, points []T, coordinates CoordinatesFunc[T], opts ...Option) (*TypedCluster[T], error) {
+	if coordinates == nil {
+		return nil, ErrInvalidCoordinatesFunc
+	}
+
+	cluster, err := readSnapshot(r, len(points), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &TypedCluster[T]{Points: points, cluster: cluster, coordinates: coordinates}
+	if err := c.useTypedPoints(); err != nil {
+		return nil, err
+	}
+
+	if err := cluster.restore(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// useTypedPoints sets access of the Cluster to the original points.
+// Returns ErrInvalidAggregator or ErrInvalidGroupFunc if typed aggregator or group function of other points type
+// is provided.
+func (c *TypedCluster[T]) useTypedPoints() error {
+	c.cluster.numLeaves = func() int {
+		return len(c.Points)
+	}
+	c.cluster.leaf = func(i int) GeoPoint {
+		return c.typedPoint(i)
+	}
+	c.cluster.leafID = func(i int) int64 {
+		return int64(i)
+	}
+	c.cluster.leafWeight = func(int) float64 {
+		return 1
+	}
+
+	if weight := typedMethod[T, weighted](); weight != nil {
+		c.cluster.leafWeight = func(i int) float64 {
+			return weightOf(weight(&c.Points[i]))
+		}
+	}
+
+	c.cluster.leafPriority = func(int) float64 {
+		return 0
+	}
+
+	if priority := typedMethod[T, prioritized](); priority != nil {
+		c.cluster.leafPriority = func(i int) float64 {
+			return priorityOf(priority(&c.Points[i]))
+		}
+	}
+
+	if c.cluster.typedMapFn != nil {
+		mapFn, ok := c.cluster.typedMapFn.(func(p T) interface{})
+		if !ok {
+			return ErrInvalidAggregator
+		}
+
+		c.cluster.mapLeaf = func(i int) interface{} {
+			return mapFn(c.Points[i])
+		}
+	} else if c.cluster.mapFn != nil {
+		c.cluster.mapLeaf = func(i int) interface{} {
+			return c.cluster.mapFn(c.typedPoint(i))
+		}
+	}
+
+	if c.cluster.typedGroupFn != nil {
+		groupFn, ok := c.cluster.typedGroupFn.(func(p T) string)
+		if !ok {
+			return ErrInvalidGroupFunc
+		}
+
+		c.cluster.leafGroup = func(i int) string {
+			return groupFn(c.Points[i])
+		}
+	} else if c.cluster.groupFn != nil {
+		c.cluster.leafGroup = func(i int) string {
+			return c.cluster.groupFn(c.typedPoint(i))
+		}
+	}
+
+	return nil
+}
+
+// typedMethod returns the function, converting the point into the value of interface M to call its method on,
+// or nil if values of type T don't implement M. Type of the points is checked once,
+// and the point is passed by the pointer to its methods, so values are not copied into interfaces for each point.
+// Points of the interface type are passed as is, as they could implement M or not.
+func typedMethod[T, M any]() func(p *T) interface{} {
+	var zero T
+
+	implements := func(v interface{}) bool {
+		_, ok := v.(M)
+
+		return ok
+	}
+
+	switch {
+	case interface{}(zero) == nil:
+		return func(p *T) interface{} {
+			return *p
+		}
+	case implements(&zero):
+		return func(p *T) interface{} {
+			return p
+		}
+	case implements(zero):
+		// pointer types, which are stored in interfaces without copying
+		return func(p *T) interface{} {
+			return *p
+		}
+	default:
+		return nil
+	}
+}
+
+func (c *TypedCluster[T]) typedPoint(i int) *TypedPoint[T] {
+	p := &TypedPoint[T]{ID: int64(i), Value: c.Points[i]}
+	p.Coordinates.Lng, p.Coordinates.Lat, _ = c.coordinates(p.Value)
+
+	return p
+}
+
+// GetLeaves returns original points of the cluster, same as Cluster.GetLeaves.
+func (c *TypedCluster[T]) GetLeaves(clusterID int64, limit, offset int) ([]T, error) {
+	points, err := c.cluster.getLeaves(clusterID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := make([]T, len(points))
+	for i, p := range points {
+		leaves[i] = c.Points[p.ID]
+	}
+
+	return leaves, nil
+}
+
+// Value returns the original point, represented by the single point p, e.g. returned by GetClusters.
+// Returns false for clusters.
+func (c *TypedCluster[T]) Value(p Point) (T, bool) {
+	if p.IsCluster(c.cluster) || p.ID < 0 || p.ID >= int64(len(c.Points)) {
+		var zero T
+
+		return zero, false
+	}
+
+	return c.Points[p.ID], true
+}
+
+// Insert adds points to the cluster without rebuilding it from scratch, same as Cluster.Insert.
+// Inserted points get IDs continuing the Points slice.
+func (c *TypedCluster[T]) Insert(points ...T) {
+	c.prepareUpdate()
+
+	offset := len(c.Points)
+	c.Points = append(c.Points, points...)
+	c.cluster.update(nil, translatePoints(points, offset, c.coordinates, c.cluster.projection, c.cluster.mapLeaf))
+}
+
+// Remove deletes all points, matching the function, from the cluster without rebuilding it from scratch,
+// same as Cluster.Remove.
+// Removed points are replaced with zero values in the Points slice to keep IDs of other points unchanged,
+// the slice is copied before the first update, so the slice passed to NewTyped is not modified.
+func (c *TypedCluster[T]) Remove(match func(p T) bool) {
+	c.prepareUpdate()
+
+	var removed []int
+
+	c.cluster.removeLeaves(func(i int) bool {
+		if match(c.Points[i]) {
+			removed = append(removed, i)
+
+			return true
+		}
+
+		return false
+	})
+
+	var zero T
+
+	for _, i := range removed {
+		c.Points[i] = zero
+	}
+}
+
+// prepareUpdate is called before each incremental update of Points.
+// It copies the points slice before the first update, as it is modified by updates.
+func (c *TypedCluster[T]) prepareUpdate() {
+	if !c.cluster.leavesCopied {
+		c.Points = append([]T(nil), c.Points...)
+		c.cluster.leavesCopied = true
+	}
+
+	c.cluster.collectClusters()
+}
+
+// GetClusters returns clusters and points of the zoom level within the area, same as Cluster.GetClusters.
+func (c *TypedCluster[T]) GetClusters(northWest, southEast GeoPoint, zoom int, limit int, groups ...string,
+) ([]Point, error) {
+	return c.cluster.GetClusters(northWest, southEast, zoom, limit, groups...)
+}
+
+// GetClustersWithContext returns clusters and points of the zoom level within the area,
+// same as Cluster.GetClustersWithContext.
+func (c *TypedCluster[T]) GetClustersWithContext(ctx context.Context, northWest, southEast GeoPoint, zoom, limit int,
+	groups ...string,
+) ([]Point, error) {
+	return c.cluster.GetClustersWithContext(ctx, northWest, southEast, zoom, limit, groups...)
+}
+
+// GetClustersFiltered returns clusters of the matching points within the area, same as Cluster.GetClustersFiltered.
+// Filter receives the original T values.
+func (c *TypedCluster[T]) GetClustersFiltered(ctx context.Context, northWest, southEast GeoPoint, zoom int,
+	filter func(p T) bool,
+) ([]Point, error) {
+	return c.cluster.getClustersFilteredWithin(ctx, northWest, southEast, zoom, func(i int) bool {
+		return filter(c.Points[i])
+	})
+}
+
+// AllClusters returns all clusters and points of the zoom level, same as Cluster.AllClusters.
+func (c *TypedCluster[T]) AllClusters(zoom int, limit int) []Point {
+	return c.cluster.AllClusters(zoom, limit)
+}
+
+// GetChildren returns children of the cluster one zoom level below, same as Cluster.GetChildren.
+func (c *TypedCluster[T]) GetChildren(clusterID int64) ([]Point, error) {
+	return c.cluster.GetChildren(clusterID)
+}
+
+// GetClustersPointsInRadius returns children of the cluster, same as Cluster.GetClustersPointsInRadius.
+func (c *TypedCluster[T]) GetClustersPointsInRadius(clusterID int64) []*Point {
+	return c.cluster.GetClustersPointsInRadius(clusterID)
+}
+
+// GetClusterBounds returns the rectangle, containing all original points of the cluster,
+// same as Cluster.GetClusterBounds.
+func (c *TypedCluster[T]) GetClusterBounds(clusterID int64) (Bounds, error) {
+	return c.cluster.GetClusterBounds(clusterID)
+}
+
+// GetBounds returns the rectangle, containing all original points, same as Cluster.GetBounds.
+func (c *TypedCluster[T]) GetBounds() Bounds {
+	return c.cluster.GetBounds()
+}
+
+// GetClusterHull returns the convex hull of the original points of the cluster, same as Cluster.GetClusterHull.
+func (c *TypedCluster[T]) GetClusterHull(clusterID int64) ([]GeoCoordinates, error) {
+	return c.cluster.GetClusterHull(clusterID)
+}
+
+// GetClusterExpansionZoom returns the zoom level the cluster expands at, same as Cluster.GetClusterExpansionZoom.
+func (c *TypedCluster[T]) GetClusterExpansionZoom(clusterID int64) int {
+	return c.cluster.GetClusterExpansionZoom(clusterID)
+}
+
+// GetTile returns points of the tile, same as Cluster.GetTile.
+func (c *TypedCluster[T]) GetTile(x, y, z int, groups ...string) []Point {
+	return c.cluster.GetTile(x, y, z, groups...)
+}
+
+// GetTileWithLatLng returns points of the tile with coordinates, same as Cluster.GetTileWithLatLng.
+func (c *TypedCluster[T]) GetTileWithLatLng(x, y, z int, groups ...string) []Point {
+	return c.cluster.GetTileWithLatLng(x, y, z, groups...)
+}
+
+// GetTileMVT returns the tile, encoded as Mapbox Vector Tile, same as Cluster.GetTileMVT.
+// LeafProperties function receives *TypedPoint[T] values.
+func (c *TypedCluster[T]) GetTileMVT(x, y, z int, opts MVTOptions) ([]byte, error) {
+	return c.cluster.GetTileMVT(x, y, z, opts)
+}
+
+// NonEmptyTiles returns coordinates of all non-empty tiles of the zoom level, same as Cluster.NonEmptyTiles.
+func (c *TypedCluster[T]) NonEmptyTiles(z int) []TileCoordinates {
+	return c.cluster.NonEmptyTiles(z)
+}
+
+// ToFeatureCollection returns GeoJSON feature collection of the points, same as Cluster.ToFeatureCollection.
+// LeafProperties function receives *TypedPoint[T] values.
+func (c *TypedCluster[T]) ToFeatureCollection(points []Point, opts GeoJSONOptions) FeatureCollection {
+	return c.cluster.ToFeatureCollection(points, opts)
+}
+
+// WritePMTiles writes tiles of the zoom range into the PMTiles archive, same as Cluster.WritePMTiles.
+func (c *TypedCluster[T]) WritePMTiles(w io.Writer, minZoom, maxZoom int, opts PMTilesOptions) error {
+	return c.cluster.WritePMTiles(w, minZoom, maxZoom, opts)
+}
+
+// WriteTo writes the binary snapshot of the cluster, same as Cluster.WriteTo, which could be loaded with LoadTyped.
+func (c *TypedCluster[T]) WriteTo(w io.Writer) (int64, error) {
+	return c.cluster.WriteTo(w)
+}
+
+// Radius returns the clustering radius of the zoom level in pixels, same as Cluster.Radius.
+func (c *TypedCluster[T]) Radius(zoom int) float64 {
+	return c.cluster.Radius(zoom)
+}
+
+// LimitZoom returns the zoom level within the zoom range of the cluster, same as Cluster.LimitZoom.
+func (c *TypedCluster[T]) LimitZoom(zoom int) int {
+	return c.cluster.LimitZoom(zoom)
+}
+
+// ZoomRange returns MinZoom and MaxZoom of the cluster.
+func (c *TypedCluster[T]) ZoomRange() (minZoom, maxZoom int) {
+	return c.cluster.MinZoom, c.cluster.MaxZoom
+}
@@ -0,0 +1,347 @@
+package cluster_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func simplePointCoordinates(p simplePoint) (lng, lat float64, ok bool) {
+	return p.Lon, p.Lat, true
+}
+
+func importTypedPoints(filename string) ([]simplePoint, []cluster.GeoPoint) {
+	geoPoints := importSimplePoints(filename)
+
+	points := make([]simplePoint, len(geoPoints))
+	for i, p := range geoPoints {
+		points[i] = p.(simplePoint)
+	}
+
+	return points, geoPoints
+}
+
+func TestNewTyped(t *testing.T) {
+	points, geoPoints := importTypedPoints("./testdata/places.json")
+
+	expected, err := cluster.New(geoPoints, cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	c, err := cluster.NewTyped(points, simplePointCoordinates, cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	assert.Nil(t, cluster.Untyped(c).Points)
+	assertSameIndexes(t, expected, cluster.Untyped(c))
+
+	for _, p := range c.AllClusters(2, -1) {
+		if !p.IsCluster(cluster.Untyped(c)) {
+			value, ok := c.Value(p)
+			require.True(t, ok)
+			assert.Equal(t, points[p.ID], value)
+
+			continue
+		}
+
+		_, ok := c.Value(p)
+		assert.False(t, ok)
+
+		expectedLeaves, err := expected.GetLeaves(p.ID, 5, 2)
+		require.NoError(t, err)
+
+		leaves, err := c.GetLeaves(p.ID, 5, 2)
+		require.NoError(t, err)
+		require.Len(t, leaves, len(expectedLeaves))
+
+		for i := range leaves {
+			assert.Equal(t, expectedLeaves[i], leaves[i])
+		}
+	}
+
+	_, err = c.GetLeaves(1, -1, 0)
+	assert.Equal(t, cluster.ErrClusterNotFound, err)
+}
+
+func TestNewTyped_SkipsPoints(t *testing.T) {
+	points := []simplePoint{{0, 10, 10}, {1, 200, 200}, {2, 10.0001, 10.0001}}
+
+	c, err := cluster.NewTyped(points, func(p simplePoint) (float64, float64, bool) {
+		return p.Lon, p.Lat, p.Lon <= 180
+	}, cluster.WithinZoom(0, 5))
+	require.NoError(t, err)
+
+	clusters := c.AllClusters(0, -1)
+	require.Len(t, clusters, 1)
+
+	leaves, err := c.GetLeaves(clusters[0].ID, -1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []simplePoint{points[0], points[2]}, leaves)
+
+	_, err = cluster.NewTyped[simplePoint](points, nil)
+	assert.Equal(t, cluster.ErrInvalidCoordinatesFunc, err)
+}
+
+func TestNewTyped_Aggregator(t *testing.T) {
+	points, geoPoints := importTypedPoints("./testdata/places.json")
+
+	sum := func(accumulated, props interface{}) interface{} {
+		return accumulated.(float64) + props.(float64)
+	}
+
+	expected, err := cluster.New(geoPoints, cluster.WithAggregator(func(p cluster.GeoPoint) interface{} {
+		return p.GetCoordinates().Lng
+	}, sum))
+	require.NoError(t, err)
+
+	c, err := cluster.NewTyped(points, simplePointCoordinates,
+		cluster.WithTypedAggregator(func(p simplePoint) interface{} {
+			return p.Lon
+		}, sum))
+	require.NoError(t, err)
+	assertSameIndexes(t, expected, cluster.Untyped(c))
+
+	geoAggregated, err := cluster.NewTyped(points, simplePointCoordinates,
+		cluster.WithAggregator(func(p cluster.GeoPoint) interface{} {
+			return p.(*cluster.TypedPoint[simplePoint]).Value.Lon
+		}, sum))
+	require.NoError(t, err)
+	assertSameIndexes(t, expected, cluster.Untyped(geoAggregated))
+
+	_, err = cluster.NewTyped(points, simplePointCoordinates,
+		cluster.WithTypedAggregator(func(p *simplePoint) interface{} {
+			return p.Lon
+		}, sum))
+	assert.Equal(t, cluster.ErrInvalidAggregator, err)
+
+	_, err = cluster.New(geoPoints, cluster.WithTypedAggregator(func(p simplePoint) interface{} {
+		return p.Lon
+	}, sum))
+	assert.Equal(t, cluster.ErrInvalidAggregator, err)
+}
+
+func TestNewTyped_GroupBy(t *testing.T) {
+	points, geoPoints := importTypedPoints("./testdata/places.json")
+
+	expected, err := cluster.New(geoPoints, cluster.WithGroupBy(func(p cluster.GeoPoint) string {
+		return fmt.Sprint(p.GetID() % 3)
+	}))
+	require.NoError(t, err)
+
+	c, err := cluster.NewTyped(points, simplePointCoordinates,
+		cluster.WithTypedGroupBy(func(p simplePoint) string {
+			return fmt.Sprint(p.ID % 3)
+		}))
+	require.NoError(t, err)
+	assertSameIndexes(t, expected, cluster.Untyped(c))
+
+	geoGrouped, err := cluster.NewTyped(points, simplePointCoordinates,
+		cluster.WithGroupBy(func(p cluster.GeoPoint) string {
+			return fmt.Sprint(p.(*cluster.TypedPoint[simplePoint]).Value.ID % 3)
+		}))
+	require.NoError(t, err)
+	assertSameIndexes(t, expected, cluster.Untyped(geoGrouped))
+
+	_, err = cluster.NewTyped(points, simplePointCoordinates,
+		cluster.WithTypedGroupBy(func(p *simplePoint) string {
+			return ""
+		}))
+	assert.Equal(t, cluster.ErrInvalidGroupFunc, err)
+
+	_, err = cluster.New(geoPoints, cluster.WithTypedGroupBy(func(p simplePoint) string {
+		return ""
+	}))
+	assert.Equal(t, cluster.ErrInvalidGroupFunc, err)
+}
+
+func TestTypedCluster_GetClustersFiltered(t *testing.T) {
+	points, geoPoints := importTypedPoints("./testdata/places.json")
+
+	expected, err := cluster.New(geoPoints, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	c, err := cluster.NewTyped(points, simplePointCoordinates, cluster.WithinZoom(0, 16))
+	require.NoError(t, err)
+
+	world := []cluster.GeoPoint{&cluster.Point{X: -180, Y: 90}, &cluster.Point{X: 180, Y: -90}}
+
+	for z := 0; z <= 16; z += 4 {
+		expectedClusters, err := expected.GetClustersFiltered(context.Background(), world[0], world[1], z,
+			func(p cluster.GeoPoint) bool {
+				return p.GetID()%2 == 0
+			})
+		require.NoError(t, err)
+
+		actual, err := c.GetClustersFiltered(context.Background(), world[0], world[1], z, func(p simplePoint) bool {
+			return p.ID%2 == 0
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expectedClusters, actual, "zoom %d", z)
+	}
+}
+
+func TestTypedCluster_InsertRemove(t *testing.T) {
+	points, geoPoints := importTypedPoints("./testdata/places.json")
+
+	expected, err := cluster.New(geoPoints[:100], cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	c, err := cluster.NewTyped(points[:100], simplePointCoordinates, cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	expected.Insert(geoPoints[100:150]...)
+	c.Insert(points[100:150]...)
+	assertSameIndexes(t, expected, cluster.Untyped(c))
+	assert.Len(t, c.Points, 150)
+
+	expected.Remove(0, 1, 120)
+	c.Remove(func(p simplePoint) bool {
+		return p.ID == 0 || p.ID == 1 || p.ID == 120
+	})
+	assertSameIndexes(t, expected, cluster.Untyped(c))
+	assertHierarchy(t, cluster.Untyped(c), 147)
+	assert.Equal(t, simplePoint{}, c.Points[120])
+	assert.Equal(t, geoPoints[0], points[0], "original slice must not be modified")
+
+	// GeoPoint values can't be mixed with the typed points
+	_, ok := interface{}(c).(interface{ Insert(...cluster.GeoPoint) })
+	assert.False(t, ok)
+}
+
+func TestTypedCluster_WriteToLoad(t *testing.T) {
+	points, _ := importTypedPoints("./testdata/places.json")
+
+	c, err := cluster.NewTyped(points, simplePointCoordinates, cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = c.WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err := cluster.LoadTyped(&buf, points, simplePointCoordinates)
+	require.NoError(t, err)
+	assertSameIndexes(t, cluster.Untyped(c), cluster.Untyped(loaded))
+
+	collection := loaded.ToFeatureCollection(loaded.AllClusters(17, -1), cluster.GeoJSONOptions{
+		LeafProperties: func(p cluster.GeoPoint) map[string]interface{} {
+			return map[string]interface{}{"id": p.(*cluster.TypedPoint[simplePoint]).Value.ID}
+		},
+	})
+
+	for _, feature := range collection.Features {
+		assert.Equal(t, *feature.ID, feature.Properties["id"])
+	}
+}
+
+func Benchmark_NewTyped(b *testing.B) {
+	geoPoints := randomPoints(100000, 100, 1)
+
+	points := make([]simplePoint, len(geoPoints))
+	for i, p := range geoPoints {
+		points[i] = p.(simplePoint)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := cluster.NewTyped(points, simplePointCoordinates); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// heavyPoint has the weight method with the pointer receiver.
+type heavyPoint struct {
+	simplePoint
+	weight float64
+}
+
+func (hp *heavyPoint) GetWeight() float64 {
+	return hp.weight
+}
+
+// assertWeights checks both weighted points are merged at zoom level 0 into the cluster of weight 4.
+func assertWeights(t *testing.T, clusters []cluster.Point) {
+	t.Helper()
+
+	require.Len(t, clusters, 1)
+	assert.InDelta(t, 1, clusters[0].X, 1e-9)
+	assert.Equal(t, 4.0, clusters[0].Weight)
+}
+
+func TestNewTyped_WeightMethods(t *testing.T) {
+	heavy := []heavyPoint{{simplePoint{0, 0, 0}, 3}, {simplePoint{1, 4, 0}, 1}}
+
+	values, err := cluster.NewTyped(heavy, func(p heavyPoint) (lng, lat float64, ok bool) {
+		return p.Lon, p.Lat, true
+	}, cluster.WithinZoom(0, 0))
+	require.NoError(t, err)
+	assertWeights(t, values.AllClusters(0, -1))
+
+	pointers, err := cluster.NewTyped([]*heavyPoint{&heavy[0], &heavy[1]}, func(p *heavyPoint) (lng, lat float64, ok bool) {
+		return p.Lon, p.Lat, true
+	}, cluster.WithinZoom(0, 0))
+	require.NoError(t, err)
+	assertWeights(t, pointers.AllClusters(0, -1))
+
+	// weights of the interface values are obtained from their dynamic types
+	geoPoints := []cluster.GeoPoint{&heavy[0], &heavy[1]}
+
+	interfaces, err := cluster.NewTyped(geoPoints, func(p cluster.GeoPoint) (lng, lat float64, ok bool) {
+		coordinates := p.GetCoordinates()
+
+		return coordinates.Lng, coordinates.Lat, true
+	}, cluster.WithinZoom(0, 0))
+	require.NoError(t, err)
+	assertWeights(t, interfaces.AllClusters(0, -1))
+}
+
+func TestNewTyped_Weights(t *testing.T) {
+	points := []weightedPoint{
+		{simplePoint{0, 0, 0}, 3},
+		{simplePoint{1, 4, 0}, 1},
+	}
+
+	c, err := cluster.NewTyped(points, func(p weightedPoint) (lng, lat float64, ok bool) {
+		return p.Lon, p.Lat, true
+	}, cluster.WithinZoom(0, 0))
+	require.NoError(t, err)
+
+	clusters := c.AllClusters(0, -1)
+	require.Len(t, clusters, 1)
+	assert.InDelta(t, 1, clusters[0].X, 1e-9)
+	assert.Equal(t, 4.0, clusters[0].Weight)
+}
+
+// importantPoint has the priority method with the pointer receiver.
+type importantPoint struct {
+	simplePoint
+	priority float64
+}
+
+func (ip *importantPoint) GetPriority() float64 {
+	return ip.priority
+}
+
+func TestNewTyped_PriorityMethods(t *testing.T) {
+	important := []importantPoint{{simplePoint{0, 0, 0}, 1}, {simplePoint{1, 4, 0}, 2}}
+	opts := []cluster.Option{cluster.WithinZoom(0, 0), cluster.WithCentroidStrategy(cluster.CentroidHighestPriority)}
+
+	values, err := cluster.NewTyped(important, func(p importantPoint) (lng, lat float64, ok bool) {
+		return p.Lon, p.Lat, true
+	}, opts...)
+	require.NoError(t, err)
+
+	pointers, err := cluster.NewTyped([]*importantPoint{&important[0], &important[1]}, func(p *importantPoint) (lng, lat float64, ok bool) {
+		return p.Lon, p.Lat, true
+	}, opts...)
+	require.NoError(t, err)
+
+	for _, clusters := range [][]cluster.Point{values.AllClusters(0, -1), pointers.AllClusters(0, -1)} {
+		require.Len(t, clusters, 1)
+		assert.InDelta(t, 4, clusters[0].X, 1e-9)
+	}
+}
@@ -0,0 +1,477 @@
+package cluster
+
+import (
+	"sort"
+
+	"github.com/electrious-go/kdbush"
+)
+
+// pendingFactor limits the number of points, inserted into the zoom level or removed from it since its KD-tree
+// was built, to pendingFactor times the square root of the number of the zoom level points.
+// Such points are checked one by one, so the limit balances these checks with rebuilds of the KD-tree.
+const pendingFactor = 4
+
+// Insert adds points to the cluster without rebuilding it from scratch.
+// Only clusters, affected by the new points, are recalculated, all other clusters keep their IDs,
+// unless other algorithm than Greedy is used, see WithAlgorithm.
+// Inserted points get IDs continuing the Points slice.
+// KD-trees are static, so inserted points are appended to Points of the KD-trees and removed points are replaced
+// with nil. KD-tree of the zoom level is rebuilt once the number of such points exceeds four times the square root
+// of the number of its points, so an update takes time sub-linear in the number of points.
+// Insert must not be called concurrently with other methods.
+func (c *Cluster) Insert(points ...GeoPoint) {
+	c.prepareUpdate()
+
+	offset := len(c.Points)
+	c.Points = append(c.Points, points...)
+	leaves := translatePoints(points, offset, geoPointCoordinates, c.projection, c.mapLeaf)
+	c.indexLeaves(offset, leaves)
+	c.update(nil, leaves)
+}
+
+// Remove deletes points with provided GeoPoint IDs from the cluster without rebuilding it from scratch.
+// All points having any of the IDs are removed, unknown IDs are ignored.
+// Removed points are replaced with nil in the Points slice to keep IDs of other points unchanged,
+// the slice is copied before the first update, so the slice passed to New is not modified.
+// Only clusters, affected by the removed points, are recalculated, all other clusters keep their IDs,
+// unless other algorithm than Greedy is used, see WithAlgorithm.
+// Remove must not be called concurrently with other methods.
+func (c *Cluster) Remove(ids ...int64) {
+	c.prepareUpdate()
+
+	removed := make(map[*Point]int64, len(ids))
+
+	var positions []int
+
+	for _, id := range ids {
+		position, ok := c.leafPositions[id]
+		if !ok {
+			continue
+		}
+
+		delete(c.leafPositions, id)
+
+		for ; position >= 0; position = c.samePositions[position] {
+			if p := c.leafPoints[position]; p != nil {
+				removed[p] = p.parentID
+				c.leafPoints[position] = nil
+			}
+
+			positions = append(positions, position)
+		}
+	}
+
+	c.update(removed, nil)
+
+	for _, position := range positions {
+		c.Points[position] = nil
+	}
+}
+
+// indexLeaves adds the original points of the Points slice from the offset to the lookups of Remove.
+// Leaves are the original points of the lowest level from the offset.
+func (c *Cluster) indexLeaves(offset int, leaves []*Point) {
+	for len(c.leafPoints) < len(c.Points) {
+		c.leafPoints = append(c.leafPoints, nil)
+		c.samePositions = append(c.samePositions, -1)
+	}
+
+	for _, p := range leaves {
+		c.leafPoints[p.ID] = p
+	}
+
+	for i := offset; i < len(c.Points); i++ {
+		if c.Points[i] == nil {
+			continue
+		}
+
+		id := c.Points[i].GetID()
+		if position, ok := c.leafPositions[id]; ok {
+			c.samePositions[i] = position
+		}
+
+		c.leafPositions[id] = i
+	}
+}
+
+// removeLeaves removes clustered original points, which positions match.
+func (c *Cluster) removeLeaves(match func(i int) bool) {
+	removed := make(map[*Point]int64)
+
+	for _, kp := range c.Indexes[len(c.Indexes)-1].Points {
+		if p := kp.(*Point); p != nil && match(int(p.ID)) {
+			removed[p] = p.parentID
+		}
+	}
+
+	c.update(removed, nil)
+}
+
+// update propagates removed and added points from the lowest level through all zoom levels,
+// until a level remains unchanged.
+// Removed points are mapped to their parent IDs before the update.
+func (c *Cluster) update(removed map[*Point]int64, added []*Point) {
+	if !c.incremental() {
+		c.rebuild(removed, added)
+
+		return
+	}
+
+	c.initLeaves(added)
+	c.patchLevel(len(c.Indexes)-1, removed, added)
+
+	for z := c.MaxZoom; z >= c.MinZoom && (len(removed) > 0 || len(added) > 0); z-- {
+		removed, added = c.updateZoom(z, removed, added)
+	}
+
+	c.hulls = nil
+}
+
+// rebuild clusters all zoom levels again from the original points without removed and with added ones.
+func (c *Cluster) rebuild(removed map[*Point]int64, added []*Point) {
+	index := c.Indexes[len(c.Indexes)-1]
+	leaves := make([]*Point, 0, len(index.Points)-len(removed)+len(added))
+
+	for _, kp := range index.Points {
+		if p := kp.(*Point); p != nil {
+			if _, ok := removed[p]; !ok {
+				leaves = append(leaves, p)
+			}
+		}
+	}
+
+	leaves = append(leaves, added...)
+	// points are clustered in the order of the original points, same as New does
+	sort.Slice(leaves, func(i, j int) bool {
+		return leaves[i].ID < leaves[j].ID
+	})
+
+	for _, p := range leaves {
+		p.parentID = 0
+	}
+	// IDs of the rebuilt clusters encode their positions again, and all points are indexed by KD-trees
+	c.clusters = nil
+	c.nextClusterIdx = nil
+	c.indexed = nil
+	c.pending = nil
+	c.build(leaves)
+	c.hulls = nil
+}
+
+// updateZoom reclusters points of the zoom level, affected by the changes of the level below.
+// Clusters, having removed points or points next to the added ones, are dissolved,
+// and their children are clustered again together with the added points.
+// The zoom level is patched, and removed and added points of the zoom level are returned.
+func (c *Cluster) updateZoom(zoom int, removedBelow map[*Point]int64, addedBelow []*Point) (map[*Point]int64, []*Point) {
+	r := c.worldRadius(zoom)
+	below := zoom + 1 - c.MinZoom
+	// points of the zoom level, that are replaced by the update, mapped to their parent IDs
+	oldRegion := make(map[*Point]int64)
+	dissolved := make(map[*Point]bool)
+	inPool := make(map[*Point]bool)
+
+	var pool []*Point
+
+	addToPool := func(p *Point) {
+		if !inPool[p] {
+			inPool[p] = true
+			pool = append(pool, p)
+		}
+	}
+	// passing point is not merged at the zoom level, so it's present at the zoom level as well
+	passing := func(parentID int64) bool {
+		return parentID == 0 || clusterZoom(parentID) < zoom
+	}
+
+	for p, parentID := range removedBelow {
+		if passing(parentID) {
+			oldRegion[p] = parentID
+		} else {
+			origin, _, _ := c.getCluster(parentID)
+			dissolved[origin] = true
+		}
+	}
+
+	for _, p := range addedBelow {
+		inPool[p] = true
+	}
+
+	for _, p := range addedBelow {
+		pool = append(pool, p)
+
+		for _, i := range c.withinLevel(below, p, r) {
+			b := c.Indexes[below].Points[i].(*Point)
+			// points of other groups are never merged with the added point
+			if inPool[b] || b.Group != p.Group {
+				continue
+			}
+
+			if passing(b.parentID) {
+				oldRegion[b] = b.parentID
+				addToPool(b)
+			} else {
+				origin, _, _ := c.getCluster(b.parentID)
+				dissolved[origin] = true
+			}
+		}
+	}
+
+	dissolvedClusters := make([]*Point, 0, len(dissolved))
+	for d := range dissolved {
+		dissolvedClusters = append(dissolvedClusters, d)
+	}
+
+	sort.Slice(dissolvedClusters, func(i, j int) bool {
+		return dissolvedClusters[i].ID < dissolvedClusters[j].ID
+	})
+
+	for _, d := range dissolvedClusters {
+		oldRegion[d] = d.parentID
+		// removed children are replaced by the update of the zoom level below
+		for _, b := range c.children(d) {
+			if _, ok := removedBelow[b]; !ok {
+				addToPool(b)
+			}
+		}
+
+		c.dissolveCluster(d)
+	}
+
+	var added []*Point
+	// keep leaves the point at the zoom level unclustered
+	keep := func(p *Point) {
+		if _, ok := oldRegion[p]; ok {
+			// point remains at the zoom level unchanged
+			delete(oldRegion, p)
+
+			return
+		}
+		// point was merged into dissolved cluster, or is new, its parent is unknown yet
+		p.parentID = 0
+		added = append(added, p)
+	}
+
+	if len(pool) > 0 {
+		poolTree := kdbush.NewBush(clustersToPoints(pool), c.NodeSize)
+
+		for _, group := range c.clusterGroups(pool, zoom, poolTree) {
+			p := pool[group[0]]
+
+			neighbours := make([]*Point, 0, len(group)-1)
+			for _, j := range group[1:] {
+				neighbours = append(neighbours, pool[j])
+			}
+
+			if !c.formsCluster(p, neighbours) {
+				keep(p)
+
+				for _, neighbour := range neighbours {
+					keep(neighbour)
+				}
+
+				continue
+			}
+
+			added = append(added, c.addCluster(zoom, p, neighbours))
+		}
+	}
+
+	c.patchLevel(zoom-c.MinZoom, oldRegion, added)
+
+	return oldRegion, added
+}
+
+// dissolveCluster removes the cluster from the clusters of the zoom level it is created at.
+// Its children remain in the children slice of the zoom level until the zoom level is compacted.
+func (c *Cluster) dissolveCluster(cluster *Point) {
+	zoom, slot, ok := c.clusterSlot(cluster.ID)
+	if !ok {
+		return
+	}
+
+	c.clusterLevels[zoom-c.MinZoom].points[slot] = nil
+	delete(c.clusters, cluster.ID)
+}
+
+// patchLevel replaces removed points of the level with nil and appends added points to Points of its KD-tree.
+// KD-tree is rebuilt once the number of such points exceeds the limit, see pendingFactor.
+func (c *Cluster) patchLevel(level int, removed map[*Point]int64, added []*Point) {
+	if len(removed) == 0 && len(added) == 0 {
+		return
+	}
+
+	index := c.Indexes[level]
+
+	for p := range removed {
+		if position := c.locate(level, p); position >= 0 {
+			index.Points[position] = (*Point)(nil)
+		}
+	}
+
+	index.Points = append(index.Points, clustersToPoints(added)...)
+
+	c.pending[level] += len(removed) + len(added)
+
+	if pending := c.pending[level]; pending > c.NodeSize &&
+		pending*pending > pendingFactor*pendingFactor*len(index.Points) {
+		c.compact(level)
+	}
+}
+
+// compact rebuilds KD-tree of the level from its points without removed ones.
+// Clusters, created at the zoom level of the level, are compacted as well.
+func (c *Cluster) compact(level int) {
+	index := c.Indexes[level]
+	points := make([]kdbush.Point, 0, len(index.Points))
+
+	for _, kp := range index.Points {
+		if p := kp.(*Point); p != nil {
+			points = append(points, p)
+		}
+	}
+
+	c.Indexes[level] = kdbush.NewBush(points, c.NodeSize)
+	c.indexed[level] = len(points)
+	c.pending[level] = 0
+
+	if level < len(c.clusterLevels) {
+		c.compactClusters(level)
+	}
+}
+
+// compactClusters removes slots and children of the dissolved clusters of the level,
+// moving slots of the clusters and their boxes.
+func (c *Cluster) compactClusters(level int) {
+	clusters := &c.clusterLevels[level]
+	compacted := levelClusters{}
+
+	for slot, p := range clusters.points {
+		if p == nil {
+			continue
+		}
+
+		c.clusters[p.ID] = len(compacted.points)
+		compacted.points = append(compacted.points, p)
+		compacted.children = append(compacted.children, clusters.childrenOf(slot)...)
+		compacted.ends = append(compacted.ends, len(compacted.children))
+		compacted.boxes = append(compacted.boxes, clusters.boxes[slot])
+
+		if clusters.centroids != nil {
+			compacted.centroids = append(compacted.centroids, clusters.centroids[slot])
+		}
+	}
+
+	*clusters = compacted
+}
+
+// locate returns position of the point in the level, or -1 if the point is not there.
+func (c *Cluster) locate(level int, p *Point) int {
+	points := c.Indexes[level].Points
+
+	for _, i := range c.rangeLevel(level, worldBox{minX: p.X, minY: p.Y, maxX: p.X, maxY: p.Y}) {
+		if points[i].(*Point) == p {
+			return i
+		}
+	}
+	// point with NaN coordinates is not within any box
+	for i, kp := range points {
+		if kp.(*Point) == p {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// rangeLevel returns positions of the points of the level within the box.
+func (c *Cluster) rangeLevel(level int, box worldBox) []int {
+	ids := c.Indexes[level].Range(box.minX, box.minY, box.maxX, box.maxY)
+
+	return c.patchPositions(level, ids, func(p *Point) bool {
+		return p.X >= box.minX && p.X <= box.maxX && p.Y >= box.minY && p.Y <= box.maxY
+	})
+}
+
+// withinLevel returns positions of the points of the level within the radius from the point.
+func (c *Cluster) withinLevel(level int, p *Point, r float64) []int {
+	ids := c.Indexes[level].Within(p, r)
+
+	return c.patchPositions(level, ids, func(b *Point) bool {
+		return sqDist(b.X, b.Y, p.X, p.Y) <= r*r
+	})
+}
+
+// patchPositions removes positions of the removed points from positions, found by KD-tree of the level,
+// and adds positions of the points, inserted after the KD-tree was built, which match.
+func (c *Cluster) patchPositions(level int, ids []int, match func(p *Point) bool) []int {
+	if c.pending == nil || c.pending[level] == 0 {
+		return ids
+	}
+
+	points := c.Indexes[level].Points
+	patched := ids[:0]
+
+	for _, i := range ids {
+		if points[i].(*Point) != nil {
+			patched = append(patched, i)
+		}
+	}
+
+	for i := c.indexed[level]; i < len(points); i++ {
+		if p := points[i].(*Point); p != nil && match(p) {
+			patched = append(patched, i)
+		}
+	}
+
+	return patched
+}
+
+// prepareUpdate is called before each incremental update of Points.
+// It copies the points slice before the first update, as it is modified by updates,
+// and collects the original points by their IDs for Remove.
+func (c *Cluster) prepareUpdate() {
+	if !c.leavesCopied {
+		c.Points = append([]GeoPoint(nil), c.Points...)
+		c.leavesCopied = true
+
+		index := c.Indexes[len(c.Indexes)-1]
+		leaves := make([]*Point, len(index.Points))
+
+		for i, kp := range index.Points {
+			leaves[i] = kp.(*Point)
+		}
+
+		c.leafPositions = make(map[int64]int, len(c.Points))
+		c.indexLeaves(0, leaves)
+	}
+
+	c.collectClusters()
+}
+
+// collectClusters is called before each incremental update.
+// Before the first update it collects slots of all clusters by their IDs, because updates change slots
+// of clusters, so cluster can't be found by the index encoded in its ID anymore.
+func (c *Cluster) collectClusters() {
+	if c.indexed == nil {
+		c.indexed = make([]int, len(c.Indexes))
+		c.pending = make([]int, len(c.Indexes))
+
+		for i, index := range c.Indexes {
+			c.indexed[i] = len(index.Points)
+		}
+	}
+
+	if c.nextClusterIdx != nil {
+		return
+	}
+
+	c.nextClusterIdx = make([]int, len(c.clusterLevels))
+
+	for i, level := range c.clusterLevels {
+		c.nextClusterIdx[i] = len(level.points)
+	}
+
+	if c.clusters == nil {
+		c.indexClusters()
+	}
+}
@@ -0,0 +1,264 @@
+package cluster_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	cluster "github.com/aliakseiz/gocluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importSimplePoints(filename string) []cluster.GeoPoint {
+	points := importData(filename)
+
+	var geoPoints []cluster.GeoPoint
+
+	for i := range points {
+		if coordinates := points[i].GetCoordinates(); coordinates != nil {
+			geoPoints = append(geoPoints, simplePoint{int64(len(geoPoints)), coordinates.Lng, coordinates.Lat})
+		}
+	}
+
+	return geoPoints
+}
+
+// assertHierarchy verifies that each zoom level contains all points and all clusters consist of their children.
+func assertHierarchy(t *testing.T, c *cluster.Cluster, numPoints int) {
+	t.Helper()
+
+	for z := c.MinZoom; z <= c.MaxZoom; z++ {
+		total := 0
+
+		for _, p := range c.AllClusters(z, -1) {
+			total += p.NumPoints
+
+			if !p.IsCluster(c) {
+				continue
+			}
+
+			children, err := c.GetChildren(p.ID)
+			require.NoError(t, err)
+
+			childrenPoints := 0
+
+			var childrenIncluded []int64
+
+			for _, child := range children {
+				childrenPoints += child.NumPoints
+				childrenIncluded = append(childrenIncluded, child.Included(c)...)
+			}
+
+			require.Equalf(t, p.NumPoints, childrenPoints, "cluster %d at zoom %d", p.ID, z)
+
+			leaves, err := c.GetLeaves(p.ID, -1, 0)
+			require.NoError(t, err)
+			require.Len(t, leaves, p.NumPoints)
+
+			included := p.Included(c)
+			sortIDs(included)
+			sortIDs(childrenIncluded)
+			require.Equalf(t, childrenIncluded, included, "leaves of cluster %d at zoom %d", p.ID, z)
+		}
+
+		require.Equalf(t, numPoints, total, "points count at zoom %d", z)
+	}
+}
+
+func sortIDs(ids []int64) {
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+}
+
+func TestCluster_InsertRemove(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+	require.NotEmpty(t, geoPoints)
+
+	c, err := cluster.New(geoPoints[:100], cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+
+	assertHierarchy(t, c, 100)
+
+	before := make(map[int64]cluster.Point)
+	for _, p := range c.AllClusters(0, -1) {
+		before[p.ID] = p
+	}
+
+	c.Insert(geoPoints[100:150]...)
+	assertHierarchy(t, c, 150)
+	assert.Len(t, c.Points, 150)
+
+	c.Remove(0, 1, 2, 120, 1000)
+	assertHierarchy(t, c, 146)
+	assert.Nil(t, c.Points[0])
+	assert.Nil(t, c.Points[120])
+
+	leaves := 0
+
+	for _, p := range c.AllClusters(c.MaxZoom+1, -1) {
+		leaves++
+
+		assert.NotNil(t, c.Points[p.ID])
+	}
+
+	assert.Equal(t, 146, leaves)
+
+	// clusters, not touched by the update, keep their IDs
+	kept := 0
+
+	for _, p := range c.AllClusters(0, -1) {
+		if b, ok := before[p.ID]; ok {
+			kept++
+
+			assert.Equal(t, b, p)
+		}
+	}
+
+	assert.NotZero(t, kept)
+	// the original slice is not modified
+	assert.NotNil(t, geoPoints[0])
+
+	// IDs of the inserted points don't collide with cluster IDs, regardless of the number of points
+	c, err = cluster.New(geoPoints[:99], cluster.WithinZoom(0, 17))
+	require.NoError(t, err)
+	c.Insert(geoPoints[99:]...)
+	assertHierarchy(t, c, len(geoPoints))
+}
+
+func TestCluster_InsertRemoveMinPoints(t *testing.T) {
+	points := []cluster.GeoPoint{
+		simplePoint{0, 10, 10},
+		simplePoint{1, 10.01, 10.01},
+	}
+
+	c, err := cluster.New(points, cluster.WithinZoom(0, 5), cluster.WithMinPoints(3))
+	require.NoError(t, err)
+	require.Len(t, c.AllClusters(0, -1), 2)
+
+	c.Insert(simplePoint{2, 10.01, 10})
+	assertHierarchy(t, c, 3)
+
+	clusters := c.AllClusters(0, -1)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, 3, clusters[0].NumPoints)
+
+	c.Remove(0)
+	assertHierarchy(t, c, 2)
+	assert.Len(t, c.AllClusters(0, -1), 2)
+}
+
+func TestCluster_InsertRemoveSame(t *testing.T) {
+	geoPoints := importSimplePoints("./testdata/places.json")
+	require.NotEmpty(t, geoPoints)
+
+	c, err := cluster.New(geoPoints[:150], cluster.WithinZoom(2, 10))
+	require.NoError(t, err)
+
+	before := c.AllClusters(2, -1)
+
+	c.Insert(simplePoint{1000, 20.8, 52.2})
+	assertHierarchy(t, c, 151)
+
+	c.Remove(1000)
+	assertHierarchy(t, c, 150)
+
+	after := c.AllClusters(2, -1)
+	assert.Equal(t, len(before), len(after))
+}
+
+func TestCluster_InsertRemoveMany(t *testing.T) {
+	points := randomPoints(600, 10, 3)
+
+	for _, opts := range [][]cluster.Option{
+		{cluster.WithinZoom(0, 12), cluster.WithNodeSize(4)},
+		{cluster.WithinZoom(2, 10), cluster.WithNodeSize(8), cluster.WithStableIDs()},
+		{cluster.WithinZoom(0, 14), cluster.WithNodeSize(4), cluster.WithCentroidStrategy(cluster.CentroidMedoid)},
+	} {
+		c, err := cluster.New(points[:300], opts...)
+		require.NoError(t, err)
+
+		random := rand.New(rand.NewSource(1))
+		numPoints, next := 300, 300
+		// small nodes make KD-trees of the zoom levels to be rebuilt after a few updates
+		for step := 1; step <= 400; step++ {
+			if random.Intn(2) == 0 {
+				c.Insert(points[next])
+				numPoints++
+				next++
+			} else if id := random.Intn(next); c.Points[id] != nil {
+				c.Remove(int64(id))
+				numPoints--
+			}
+
+			if step%50 != 0 {
+				continue
+			}
+
+			assertHierarchy(t, c, numPoints)
+			// loaded cluster has the same points, indexed by KD-trees
+			var buf bytes.Buffer
+
+			_, err := c.WriteTo(&buf)
+			require.NoError(t, err)
+
+			loaded, err := cluster.Load(&buf, c.Points, opts...)
+			require.NoError(t, err)
+			assertSameIndexes(t, c, loaded)
+
+			northWest, southEast := simplePoint{-1, -180, 85}, simplePoint{-1, 180, -85}
+
+			for z := c.MinZoom; z <= c.MaxZoom; z++ {
+				expected, err := loaded.GetClusters(northWest, southEast, z, -1)
+				require.NoError(t, err)
+
+				actual, err := c.GetClusters(northWest, southEast, z, -1)
+				require.NoError(t, err)
+				require.ElementsMatch(t, expected, actual)
+				require.ElementsMatch(t, loaded.GetTile(0, 0, z), c.GetTile(0, 0, z))
+
+				for _, p := range actual {
+					if !p.IsCluster(c) {
+						continue
+					}
+
+					expectedBounds, err := loaded.GetClusterBounds(p.ID)
+					require.NoError(t, err)
+
+					bounds, err := c.GetClusterBounds(p.ID)
+					require.NoError(t, err)
+					require.Equal(t, expectedBounds, bounds)
+				}
+			}
+
+			assert.Equal(t, loaded.GetBounds(), c.GetBounds())
+		}
+	}
+}
+
+// cpu: Intel(R) Xeon(R) Processor
+// Benchmark_InsertRemove/points=10000     	    3253	    361744 ns/op
+// Benchmark_InsertRemove/points=100000    	    1069	   1121465 ns/op
+// Benchmark_InsertRemove/points=1000000   	     316	   3770120 ns/op
+func Benchmark_InsertRemove(b *testing.B) {
+	for _, n := range []int{10000, 100000, 1000000} {
+		b.Run(fmt.Sprintf("points=%d", n), func(b *testing.B) {
+			points := randomPoints(n+1, 0, 5)
+
+			c, err := cluster.New(points[:n])
+			require.NoError(b, err)
+			// the first update copies the points
+			c.Insert(points[n])
+			c.Remove(points[n].GetID())
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				c.Insert(points[n])
+				c.Remove(points[n].GetID())
+			}
+		})
+	}
+}